@@ -0,0 +1,107 @@
+package accountstore_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/money"
+)
+
+// benchAccounts is how many accounts BenchmarkLeg spreads its legs over.
+const benchAccounts = 1000
+
+// locker is what a benchmark serialises its legs with.
+type locker interface {
+	Lock(ids ...string) (unlock func())
+}
+
+// globalLock locks the whole store whatever the account, the way the bank
+// worked before it had per-account locks.
+type globalLock struct{ mu sync.Mutex }
+
+func (g *globalLock) Lock(ids ...string) func() {
+	g.mu.Lock()
+	return g.mu.Unlock
+}
+
+// BenchmarkLeg measures one side of a transfer as a bank does it: lock the
+// account, place a hold and post it, so every leg writes to the store twice.
+// Legs pick accounts at random and run in parallel; -cpu sets how many run
+// at once:
+//
+//	go test -run=NONE -bench=Leg -cpu=1,8,64 ./accountstore
+func BenchmarkLeg(b *testing.B) {
+	for _, kind := range []string{accountstore.KindLog, accountstore.KindCSV} {
+		for _, l := range []struct {
+			name  string
+			locks func() locker
+		}{
+			{"per-account", func() locker { return &accountstore.AccountLocks{} }},
+			{"global", func() locker { return &globalLock{} }},
+		} {
+			b.Run(kind+"/"+l.name, func(b *testing.B) {
+				benchmarkLeg(b, kind, l.locks())
+			})
+		}
+	}
+}
+
+func benchmarkLeg(b *testing.B, kind string, locks locker) {
+	store, err := accountstore.Open(kind, b.TempDir(), "BENCH")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer store.Close()
+	for i := 0; i < benchAccounts; i++ {
+		err := store.Create(accountstore.Account{
+			ID:       fmt.Sprintf("ACC%d", i),
+			Username: fmt.Sprintf("user%d", i),
+			BankName: "BENCH",
+			Balance:  money.New(1_000_000_00, money.DefaultCurrency),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	var next, seed int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		rng := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+		for pb.Next() {
+			n := atomic.AddInt64(&next, 1)
+			accountID := fmt.Sprintf("ACC%d", rng.Intn(benchAccounts))
+			if err := leg(store, locks, n, accountID); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// leg holds and then posts a debit of one unit from accountID.
+func leg(store accountstore.Store, locks locker, n int64, accountID string) error {
+	defer locks.Lock(accountID)()
+	key := "leg-" + strconv.FormatInt(n, 10)
+	amount := money.New(1, money.DefaultCurrency)
+	now := time.Now()
+	err := store.PutHold(accountstore.Hold{
+		Key:           key,
+		TransactionID: key,
+		Operation:     "debit",
+		AccountID:     accountID,
+		Counterparty:  "BENCH",
+		Amount:        amount,
+		PreparedAt:    now,
+		Deadline:      now.Add(time.Minute),
+	})
+	if err != nil {
+		return err
+	}
+	return store.Post(accountstore.Posting{Key: key, AccountID: accountID, Operation: "debit", Amount: amount})
+}
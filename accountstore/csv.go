@@ -0,0 +1,311 @@
+package accountstore
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
+)
+
+// CSVStore keeps accounts in <BANK>_users.txt and holds in
+// <BANK>_prepared.txt, the files the bank has always used. Both are loaded
+// when the store is opened and every change rewrites the file it touches
+// through safefile, so the files should only be edited while the bank is
+// stopped. Rewriting a whole file for each change means changes run one at
+// a time; LogStore lets changes to different accounts run in parallel.
+//
+// A posting that drops a hold touches both files. The prepared file is
+// rewritten first with the account's balance before the posting added to
+// the hold's row, then the users file, then the prepared file without the
+// hold. If the bank stops in between, opening the store compares the
+// account's balance with the one recorded: if it changed, the posting was
+// applied and the hold is dropped, otherwise the hold stays.
+type CSVStore struct {
+	mu           sync.RWMutex
+	usersFile    string
+	preparedFile string
+	accounts     map[string]Account
+	holds        map[string]Hold
+	posting      map[string]*pb.Money // key -> balance before, while a hold is posted
+}
+
+// OpenCSV opens the CSV store of bankName in dir, creating an empty users
+// file if there is none.
+func OpenCSV(dir, bankName string) (*CSVStore, error) {
+	s := &CSVStore{
+		usersFile:    filepath.Join(dir, bankName+"_users.txt"),
+		preparedFile: filepath.Join(dir, bankName+"_prepared.txt"),
+		accounts:     make(map[string]Account),
+		holds:        make(map[string]Hold),
+		posting:      make(map[string]*pb.Money),
+	}
+	records, err := ReadUsersFile(s.usersFile)
+	if os.IsNotExist(err) {
+		if err := WriteUsersFile(s.usersFile, [][]string{strings.Split(UsersFileHeader, ",")}); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if len(records) > 0 && strings.Join(records[0], ",") != UsersFileHeader {
+		return nil, fmt.Errorf("%s is not in the current format (%s); convert it with migrate_money first", s.usersFile, UsersFileHeader)
+	}
+	for i, record := range records {
+		if i == 0 {
+			continue
+		}
+		acct, err := parseAccount(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", s.usersFile, i+1, err)
+		}
+		s.accounts[acct.ID] = acct
+	}
+
+	data, err := safefile.ReadFile(s.preparedFile)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", s.preparedFile, err)
+	}
+	interrupted := false
+	for _, row := range rows {
+		var before *pb.Money
+		if len(row) == 9 {
+			if before, err = money.Parse(row[8]); err != nil {
+				log.Printf("AccountStore: Skipping record in %s: hold %s has invalid balance %q", s.preparedFile, row[0], row[8])
+				continue
+			}
+			row = row[:8]
+		}
+		h, err := parseHold(row)
+		if err != nil {
+			log.Printf("AccountStore: Skipping record in %s: %v", s.preparedFile, err)
+			continue
+		}
+		if before != nil {
+			// A posting of the hold was interrupted.
+			interrupted = true
+			if acct, ok := s.accounts[h.AccountID]; ok && !money.Equal(acct.Balance, before) {
+				log.Printf("AccountStore: Dropping hold %s, posted before the bank stopped", h.Key)
+				continue
+			}
+		}
+		s.holds[h.Key] = h
+	}
+	if interrupted {
+		if err := s.saveHoldsLocked(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// ReadUsersFile reads a <BANK>_users.txt file, header included.
+func ReadUsersFile(path string) ([][]string, error) {
+	data, err := safefile.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return csv.NewReader(bytes.NewReader(data)).ReadAll()
+}
+
+// WriteUsersFile replaces a <BANK>_users.txt file with records.
+func WriteUsersFile(path string, records [][]string) error {
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	return safefile.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func (s *CSVStore) Get(id string) (Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	acct, ok := s.accounts[id]
+	if !ok {
+		return Account{}, fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+	}
+	return cloneAccount(acct), nil
+}
+
+func (s *CSVStore) List() ([]Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedAccounts(s.accounts), nil
+}
+
+func (s *CSVStore) Create(acct Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.accounts[acct.ID]; ok {
+		return fmt.Errorf("%w: %s", ErrAccountExists, acct.ID)
+	}
+	if _, err := parseAccount(accountFields(acct)); err != nil {
+		return err
+	}
+	s.accounts[acct.ID] = cloneAccount(acct)
+	if err := s.saveAccountsLocked(); err != nil {
+		delete(s.accounts, acct.ID)
+		return err
+	}
+	return nil
+}
+
+func (s *CSVStore) Remove(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acct, ok := s.accounts[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+	}
+	if err := checkRemovable(acct, s.holds); err != nil {
+		return err
+	}
+	delete(s.accounts, id)
+	if err := s.saveAccountsLocked(); err != nil {
+		s.accounts[id] = acct
+		return err
+	}
+	return nil
+}
+
+func (s *CSVStore) Post(p Posting) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acct, ok := s.accounts[p.AccountID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, p.AccountID)
+	}
+	balance, err := applyPosting(acct.Balance, p)
+	if err != nil {
+		return err
+	}
+	old := acct
+	h, held := s.holds[p.Key]
+	held = held && h.AccountID == p.AccountID
+	if held {
+		s.posting[p.Key] = old.Balance
+		if err := s.saveHoldsLocked(); err != nil {
+			delete(s.posting, p.Key)
+			return err
+		}
+	}
+	acct.Balance = balance
+	s.accounts[acct.ID] = acct
+	if err := s.saveAccountsLocked(); err != nil {
+		s.accounts[acct.ID] = old
+		if held {
+			// Left on file, the balance recorded with the hold still
+			// shows the posting was not applied.
+			delete(s.posting, p.Key)
+			s.saveHoldsLocked()
+		}
+		return err
+	}
+	if held {
+		delete(s.posting, p.Key)
+		delete(s.holds, p.Key)
+		if err := s.saveHoldsLocked(); err != nil {
+			// The posting stands: the balance recorded with the hold
+			// shows it was applied, so the hold is dropped when the store
+			// is next opened, if no other change rewrites the file first.
+			log.Printf("AccountStore: Could not drop posted hold %s from %s: %v", p.Key, s.preparedFile, err)
+		}
+	}
+	return nil
+}
+
+func (s *CSVStore) PutHold(h Hold) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := checkHoldKey(h, s.holds); err != nil {
+		return err
+	}
+	old, existed := s.holds[h.Key]
+	s.holds[h.Key] = cloneHold(h)
+	if err := s.saveHoldsLocked(); err != nil {
+		if existed {
+			s.holds[h.Key] = old
+		} else {
+			delete(s.holds, h.Key)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *CSVStore) ReleaseHold(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.holds[key]
+	if !ok {
+		return nil
+	}
+	delete(s.holds, key)
+	if err := s.saveHoldsLocked(); err != nil {
+		s.holds[key] = old
+		return err
+	}
+	return nil
+}
+
+func (s *CSVStore) Holds() ([]Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedHolds(s.holds), nil
+}
+
+func (s *CSVStore) Close() error { return nil }
+
+// saveAccountsLocked rewrites the users file from memory.
+func (s *CSVStore) saveAccountsLocked() error {
+	records := [][]string{strings.Split(UsersFileHeader, ",")}
+	for _, acct := range sortedAccounts(s.accounts) {
+		records = append(records, accountFields(acct))
+	}
+	return WriteUsersFile(s.usersFile, records)
+}
+
+// saveHoldsLocked rewrites the prepared file from memory.
+func (s *CSVStore) saveHoldsLocked() error {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, h := range sortedHolds(s.holds) {
+		fields := holdFields(h)
+		if before, ok := s.posting[h.Key]; ok {
+			fields = append(fields, money.Format(before))
+		}
+		if err := writer.Write(fields); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	return safefile.WriteFile(s.preparedFile, buf.Bytes(), 0644)
+}
+
+func sortedHolds(holds map[string]Hold) []Hold {
+	list := make([]Hold, 0, len(holds))
+	for _, h := range holds {
+		list = append(list, cloneHold(h))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
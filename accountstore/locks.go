@@ -0,0 +1,62 @@
+package accountstore
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+)
+
+// lockStripes is how many mutexes AccountLocks shares out among accounts.
+// Two accounts on the same stripe wait for each other needlessly, so it is
+// well above the number of transfers a bank handles at once.
+const lockStripes = 256
+
+// AccountLocks serialises work on each account while letting work on
+// different accounts run in parallel. Accounts are hashed onto a fixed set
+// of mutexes. Lock takes the mutexes of several accounts in stripe order,
+// so callers locking overlapping sets never deadlock, whatever order they
+// name the accounts in. The zero value is ready to use.
+type AccountLocks struct {
+	stripes [lockStripes]sync.Mutex
+}
+
+func stripeOf(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % lockStripes)
+}
+
+// Lock locks the accounts ids and returns the function that unlocks them.
+func (l *AccountLocks) Lock(ids ...string) (unlock func()) {
+	stripes := make([]int, 0, len(ids))
+	for _, id := range ids {
+		stripes = append(stripes, stripeOf(id))
+	}
+	sort.Ints(stripes)
+	var held []int
+	for _, s := range stripes {
+		if len(held) > 0 && held[len(held)-1] == s {
+			continue
+		}
+		l.stripes[s].Lock()
+		held = append(held, s)
+	}
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			l.stripes[held[i]].Unlock()
+		}
+	}
+}
+
+// LockAll locks every account, for work that needs the whole store to hold
+// still.
+func (l *AccountLocks) LockAll() (unlock func()) {
+	for i := range l.stripes {
+		l.stripes[i].Lock()
+	}
+	return func() {
+		for i := len(l.stripes) - 1; i >= 0; i-- {
+			l.stripes[i].Unlock()
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package accountstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
+)
+
+// snapshotEvery is how many log records LogStore appends before it folds
+// them into a new snapshot.
+const snapshotEvery = 1000
+
+// LogStore keeps accounts and holds in memory and makes every change
+// durable by appending one record to <BANK>_accounts.log and syncing it
+// before the change is applied. Every so often the whole state is written
+// to <BANK>_accounts.snapshot and the log starts over.
+//
+// Each line is a CSV record "seq,type,fields...,crc" where crc is the
+// CRC-32 of the line before it, so a record torn by a crash is recognised.
+// A torn last record is dropped when the store is opened; damage anywhere
+// else stops the store from opening rather than losing history silently.
+//
+// Changes to different accounts run in parallel: a change holds only the
+// lock of its account while its record is written, synced and applied, and
+// records written while another change waits for the disk are synced
+// together with it. If a sync fails the store cannot tell which records
+// reached the disk, so it refuses further changes until it is reopened.
+type LogStore struct {
+	locks        AccountLocks // held by a change from check to apply
+	mu           sync.RWMutex // guards the fields below and writes to log
+	logFile      string
+	snapshotFile string
+	log          *os.File
+	size         int64  // length of the log up to the last whole record
+	seq          uint64 // last sequence number written
+	sinceSnap    int    // records in the log
+	failed       error  // why the store refuses changes, if it does
+	accounts     map[string]Account
+	holds        map[string]Hold
+
+	syncMu sync.Mutex // serialises syncs of log
+	synced uint64     // last sequence number known to be on disk
+}
+
+// Record types.
+const (
+	recSnapshot = "snapshot" // first line of a snapshot
+	recEnd      = "end"      // last line of a snapshot: number of records
+	recAccount  = "account"  // account created, or its state in a snapshot
+	recRemove   = "remove"
+	recPost     = "post"
+	recHold     = "hold"
+	recRelease  = "release"
+)
+
+// OpenLog opens the log store of bankName in dir, creating it if it does
+// not exist.
+func OpenLog(dir, bankName string) (*LogStore, error) {
+	s := &LogStore{
+		logFile:      filepath.Join(dir, bankName+"_accounts.log"),
+		snapshotFile: filepath.Join(dir, bankName+"_accounts.snapshot"),
+		accounts:     make(map[string]Account),
+		holds:        make(map[string]Hold),
+	}
+	if err := s.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	validEnd, err := s.replayLog()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.logFile, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	// Cut off a torn last record so new records follow whole ones.
+	if err := f.Truncate(validEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(validEnd, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	s.log = f
+	s.size = validEnd
+	s.synced = s.seq
+	return s, nil
+}
+
+// encodeRecord renders one line, checksum and newline included.
+func encodeRecord(seq uint64, typ string, fields ...string) []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(append([]string{strconv.FormatUint(seq, 10), typ}, fields...))
+	w.Flush()
+	body := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	return append(body, []byte(fmt.Sprintf(",%08x\n", crc32.ChecksumIEEE(body)))...)
+}
+
+// decodeRecord checks and splits one line, without its newline.
+func decodeRecord(line []byte) (seq uint64, typ string, fields []string, err error) {
+	cut := bytes.LastIndexByte(line, ',')
+	if cut < 0 {
+		return 0, "", nil, fmt.Errorf("no checksum")
+	}
+	body := line[:cut]
+	if fmt.Sprintf("%08x", crc32.ChecksumIEEE(body)) != string(line[cut+1:]) {
+		return 0, "", nil, fmt.Errorf("checksum mismatch")
+	}
+	row, err := csv.NewReader(bytes.NewReader(body)).Read()
+	if err != nil {
+		return 0, "", nil, err
+	}
+	if len(row) < 2 {
+		return 0, "", nil, fmt.Errorf("record too short")
+	}
+	seq, err = strconv.ParseUint(row[0], 10, 64)
+	if err != nil {
+		return 0, "", nil, fmt.Errorf("bad sequence number %q", row[0])
+	}
+	return seq, row[1], row[2:], nil
+}
+
+// applyRecord replays one record onto the in-memory state.
+func (s *LogStore) applyRecord(typ string, fields []string) error {
+	switch typ {
+	case recAccount:
+		acct, err := parseAccount(fields)
+		if err != nil {
+			return err
+		}
+		s.accounts[acct.ID] = acct
+	case recRemove:
+		if len(fields) != 1 {
+			return fmt.Errorf("remove record has %d fields", len(fields))
+		}
+		delete(s.accounts, fields[0])
+	case recPost:
+		if len(fields) != 4 {
+			return fmt.Errorf("post record has %d fields", len(fields))
+		}
+		amount, err := money.Parse(fields[3])
+		if err != nil {
+			return err
+		}
+		p := Posting{Key: fields[0], AccountID: fields[1], Operation: fields[2], Amount: amount}
+		acct, ok := s.accounts[p.AccountID]
+		if !ok {
+			return fmt.Errorf("posting %s to unknown account %s", p.Key, p.AccountID)
+		}
+		if acct.Balance, err = applyPosting(acct.Balance, p); err != nil {
+			return err
+		}
+		s.accounts[acct.ID] = acct
+		if h, ok := s.holds[p.Key]; ok && h.AccountID == p.AccountID {
+			delete(s.holds, p.Key)
+		}
+	case recHold:
+		h, err := parseHold(fields)
+		if err != nil {
+			return err
+		}
+		s.holds[h.Key] = h
+	case recRelease:
+		if len(fields) != 1 {
+			return fmt.Errorf("release record has %d fields", len(fields))
+		}
+		delete(s.holds, fields[0])
+	default:
+		return fmt.Errorf("unknown record type %q", typ)
+	}
+	return nil
+}
+
+// loadSnapshot reads the snapshot, if there is one. Snapshots are renamed
+// into place whole, so any damage is reported.
+func (s *LogStore) loadSnapshot() error {
+	data, err := os.ReadFile(s.snapshotFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	count := 0
+	for i, line := range lines {
+		seq, typ, fields, err := decodeRecord(line)
+		if err != nil {
+			return fmt.Errorf("%s line %d: %v", s.snapshotFile, i+1, err)
+		}
+		switch {
+		case i == 0:
+			if typ != recSnapshot {
+				return fmt.Errorf("%s does not start with a snapshot record", s.snapshotFile)
+			}
+			s.seq = seq
+		case typ == recEnd:
+			if i != len(lines)-1 || len(fields) != 1 || fields[0] != strconv.Itoa(count) {
+				return fmt.Errorf("%s is incomplete", s.snapshotFile)
+			}
+			return nil
+		default:
+			if err := s.applyRecord(typ, fields); err != nil {
+				return fmt.Errorf("%s line %d: %v", s.snapshotFile, i+1, err)
+			}
+			count++
+		}
+	}
+	return fmt.Errorf("%s is incomplete", s.snapshotFile)
+}
+
+// replayLog applies the log records newer than the snapshot and returns the
+// offset just past the last whole record.
+func (s *LogStore) replayLog() (int64, error) {
+	f, err := os.Open(s.logFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	reader := bufio.NewReader(f)
+	var offset int64
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				log.Printf("AccountStore: Dropping torn record at the end of %s", s.logFile)
+			}
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		seq, typ, fields, decodeErr := decodeRecord(line[:len(line)-1])
+		if decodeErr != nil {
+			if _, peekErr := reader.Peek(1); peekErr == io.EOF {
+				log.Printf("AccountStore: Dropping torn record at the end of %s: %v", s.logFile, decodeErr)
+				return offset, nil
+			}
+			return 0, fmt.Errorf("%s line %d: %v", s.logFile, lineNo, decodeErr)
+		}
+		offset += int64(len(line))
+		if seq <= s.seq {
+			// Already in the snapshot; the log was not yet cut when the
+			// snapshot was taken.
+			continue
+		}
+		if seq != s.seq+1 {
+			return 0, fmt.Errorf("%s line %d: sequence %d follows %d", s.logFile, lineNo, seq, s.seq)
+		}
+		if err := s.applyRecord(typ, fields); err != nil {
+			return 0, fmt.Errorf("%s line %d: %v", s.logFile, lineNo, err)
+		}
+		s.seq = seq
+		s.sinceSnap++
+	}
+}
+
+// errNoChange is returned by a check to finish a change without writing
+// anything.
+var errNoChange = errors.New("no change")
+
+// commit makes one change. The caller holds the locks of the accounts it
+// touches. check runs under mu and may refuse the change; the record is
+// then written and synced, and apply, also under mu, updates memory.
+func (s *LogStore) commit(check func() error, apply func(), typ string, fields ...string) error {
+	s.mu.Lock()
+	if err := check(); err != nil {
+		s.mu.Unlock()
+		if err == errNoChange {
+			return nil
+		}
+		return err
+	}
+	seq, err := s.writeLocked(typ, fields...)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := s.syncTo(seq); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	apply()
+	s.mu.Unlock()
+	return nil
+}
+
+// writeLocked writes one record to the log without syncing it and returns
+// its sequence number.
+func (s *LogStore) writeLocked(typ string, fields ...string) (uint64, error) {
+	if s.failed != nil {
+		return 0, s.failed
+	}
+	if s.log == nil {
+		return 0, fmt.Errorf("account store is closed")
+	}
+	// Records are read back line by line.
+	for _, field := range fields {
+		if strings.ContainsAny(field, "\r\n") {
+			return 0, fmt.Errorf("field %q contains a line break", field)
+		}
+	}
+	line := encodeRecord(s.seq+1, typ, fields...)
+	if _, err := s.log.Write(line); err != nil {
+		// Drop whatever part of the record got out, so that the next one
+		// does not follow a torn record.
+		if s.log.Truncate(s.size) == nil {
+			s.log.Seek(s.size, io.SeekStart)
+		}
+		return 0, err
+	}
+	s.size += int64(len(line))
+	s.seq++
+	s.sinceSnap++
+	return s.seq, nil
+}
+
+// syncTo returns once the record seq is on disk. One sync covers every
+// record written before it started, so callers that arrive while a sync is
+// running usually find their record already synced.
+func (s *LogStore) syncTo(seq uint64) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if s.synced >= seq {
+		return nil
+	}
+	s.mu.Lock()
+	f, last, failed := s.log, s.seq, s.failed
+	s.mu.Unlock()
+	if failed != nil {
+		return failed
+	}
+	if err := f.Sync(); err != nil {
+		s.mu.Lock()
+		s.failed = fmt.Errorf("%s could not be synced, reopen the store: %v", s.logFile, err)
+		s.mu.Unlock()
+		log.Printf("AccountStore: %v", s.failed)
+		return s.failed
+	}
+	s.synced = last
+	return nil
+}
+
+// maybeSnapshot takes a snapshot once the log has grown long enough. It
+// is called with no account locked. A failed snapshot leaves the log as it
+// was, which is still complete.
+func (s *LogStore) maybeSnapshot() {
+	s.mu.Lock()
+	due := s.sinceSnap >= snapshotEvery && s.failed == nil && s.log != nil
+	s.mu.Unlock()
+	if !due {
+		return
+	}
+	unlock := s.locks.LockAll()
+	defer unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sinceSnap < snapshotEvery || s.failed != nil || s.log == nil {
+		// Another change took it first.
+		return
+	}
+	if err := s.snapshotLocked(); err != nil {
+		log.Printf("AccountStore: Could not write snapshot %s: %v", s.snapshotFile, err)
+	}
+}
+
+// Snapshot writes the current state to the snapshot file and empties the
+// log.
+func (s *LogStore) Snapshot() error {
+	unlock := s.locks.LockAll()
+	defer unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failed != nil {
+		return s.failed
+	}
+	if s.log == nil {
+		return fmt.Errorf("account store is closed")
+	}
+	return s.snapshotLocked()
+}
+
+// snapshotLocked needs every account lock as well as mu, so that no change
+// is between writing its record and applying it.
+func (s *LogStore) snapshotLocked() error {
+	var buf bytes.Buffer
+	buf.Write(encodeRecord(s.seq, recSnapshot))
+	count := 0
+	for _, acct := range sortedAccounts(s.accounts) {
+		buf.Write(encodeRecord(s.seq, recAccount, accountFields(acct)...))
+		count++
+	}
+	for _, h := range sortedHolds(s.holds) {
+		buf.Write(encodeRecord(s.seq, recHold, holdFields(h)...))
+		count++
+	}
+	buf.Write(encodeRecord(s.seq, recEnd, strconv.Itoa(count)))
+
+	if err := safefile.Replace(s.snapshotFile, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	// Records up to s.seq are now in the snapshot; if the process stops
+	// before the log is cut they are skipped on replay.
+	if err := s.log.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := s.log.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	s.size = 0
+	s.sinceSnap = 0
+	return s.log.Sync()
+}
+
+func sortedAccounts(accounts map[string]Account) []Account {
+	list := make([]Account, 0, len(accounts))
+	for _, acct := range accounts {
+		list = append(list, cloneAccount(acct))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+func (s *LogStore) Get(id string) (Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	acct, ok := s.accounts[id]
+	if !ok {
+		return Account{}, fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+	}
+	return cloneAccount(acct), nil
+}
+
+func (s *LogStore) List() ([]Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedAccounts(s.accounts), nil
+}
+
+func (s *LogStore) Create(acct Account) error {
+	defer s.maybeSnapshot()
+	defer s.locks.Lock(acct.ID)()
+	// Check the row the way replay will read it.
+	fields := accountFields(acct)
+	if _, err := parseAccount(fields); err != nil {
+		return err
+	}
+	return s.commit(func() error {
+		if _, ok := s.accounts[acct.ID]; ok {
+			return fmt.Errorf("%w: %s", ErrAccountExists, acct.ID)
+		}
+		return nil
+	}, func() {
+		s.accounts[acct.ID] = cloneAccount(acct)
+	}, recAccount, fields...)
+}
+
+func (s *LogStore) Remove(id string) error {
+	defer s.maybeSnapshot()
+	defer s.locks.Lock(id)()
+	return s.commit(func() error {
+		acct, ok := s.accounts[id]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, id)
+		}
+		return checkRemovable(acct, s.holds)
+	}, func() {
+		delete(s.accounts, id)
+	}, recRemove, id)
+}
+
+func (s *LogStore) Post(p Posting) error {
+	defer s.maybeSnapshot()
+	defer s.locks.Lock(p.AccountID)()
+	var balance *pb.Money
+	return s.commit(func() error {
+		acct, ok := s.accounts[p.AccountID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrAccountNotFound, p.AccountID)
+		}
+		var err error
+		balance, err = applyPosting(acct.Balance, p)
+		return err
+	}, func() {
+		acct := s.accounts[p.AccountID]
+		acct.Balance = balance
+		s.accounts[acct.ID] = acct
+		if h, ok := s.holds[p.Key]; ok && h.AccountID == p.AccountID {
+			delete(s.holds, p.Key)
+		}
+	}, recPost, p.Key, p.AccountID, p.Operation, money.Format(p.Amount))
+}
+
+func (s *LogStore) PutHold(h Hold) error {
+	defer s.maybeSnapshot()
+	defer s.locks.Lock(h.AccountID)()
+	return s.commit(func() error {
+		return checkHoldKey(h, s.holds)
+	}, func() {
+		s.holds[h.Key] = cloneHold(h)
+	}, recHold, holdFields(h)...)
+}
+
+func (s *LogStore) ReleaseHold(key string) error {
+	s.mu.RLock()
+	h, ok := s.holds[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	defer s.maybeSnapshot()
+	defer s.locks.Lock(h.AccountID)()
+	return s.commit(func() error {
+		if _, ok := s.holds[key]; !ok {
+			// Released while this call waited for the lock.
+			return errNoChange
+		}
+		return nil
+	}, func() {
+		delete(s.holds, key)
+	}, recRelease, key)
+}
+
+func (s *LogStore) Holds() ([]Hold, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sortedHolds(s.holds), nil
+}
+
+func (s *LogStore) Close() error {
+	defer s.locks.LockAll()()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.log == nil {
+		return nil
+	}
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
@@ -0,0 +1,81 @@
+package accountstore
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"payment_gateway/money"
+)
+
+// UsersFileHeader is the first line of <BANK>_users.txt. Balances are kept
+// in minor units of the account's currency.
+const UsersFileHeader = "AccountId,username,password,bank_name,balance_minor,currency"
+
+// accountFields is the <BANK>_users.txt row of acct.
+func accountFields(acct Account) []string {
+	return []string{
+		acct.ID, acct.Username, acct.PasswordHash, acct.BankName,
+		strconv.FormatInt(acct.Balance.GetMinorUnits(), 10), acct.Balance.GetCurrencyCode(),
+	}
+}
+
+// parseAccount reads a <BANK>_users.txt row.
+func parseAccount(fields []string) (Account, error) {
+	if len(fields) != 6 {
+		return Account{}, fmt.Errorf("account record has %d fields, expected 6", len(fields))
+	}
+	minor, err := strconv.ParseInt(fields[4], 10, 64)
+	if err != nil {
+		return Account{}, fmt.Errorf("invalid balance %q for account %s", fields[4], fields[0])
+	}
+	balance := money.New(minor, fields[5])
+	if err := money.Validate(balance); err != nil {
+		return Account{}, fmt.Errorf("invalid balance for account %s: %v", fields[0], err)
+	}
+	return Account{ID: fields[0], Username: fields[1], PasswordHash: fields[2], BankName: fields[3], Balance: balance}, nil
+}
+
+// holdFields is the <BANK>_prepared.txt row of h.
+func holdFields(h Hold) []string {
+	return []string{
+		h.Key, h.TransactionID, h.Operation, h.AccountID, h.Counterparty,
+		money.Format(h.Amount),
+		h.PreparedAt.UTC().Format(time.RFC3339Nano),
+		h.Deadline.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// parseHold reads a <BANK>_prepared.txt row.
+func parseHold(fields []string) (Hold, error) {
+	if len(fields) != 8 {
+		return Hold{}, fmt.Errorf("hold record has %d fields, expected 8", len(fields))
+	}
+	amount, err := money.Parse(fields[5])
+	if err != nil {
+		return Hold{}, fmt.Errorf("hold %s has invalid amount %q", fields[0], fields[5])
+	}
+	preparedAt, _ := time.Parse(time.RFC3339Nano, fields[6])
+	deadline, _ := time.Parse(time.RFC3339Nano, fields[7])
+	return Hold{
+		Key:           fields[0],
+		TransactionID: fields[1],
+		Operation:     fields[2],
+		AccountID:     fields[3],
+		Counterparty:  fields[4],
+		Amount:        amount,
+		PreparedAt:    preparedAt,
+		Deadline:      deadline,
+	}, nil
+}
+
+// cloneAccount copies acct so callers cannot change the store's balance.
+func cloneAccount(acct Account) Account {
+	acct.Balance = money.New(acct.Balance.GetMinorUnits(), acct.Balance.GetCurrencyCode())
+	return acct
+}
+
+func cloneHold(h Hold) Hold {
+	h.Amount = money.New(h.Amount.GetMinorUnits(), h.Amount.GetCurrencyCode())
+	return h
+}
@@ -0,0 +1,155 @@
+// Package accountstore keeps a bank's accounts and the holds placed on them
+// by prepared transactions. Store is implemented by a CSV store, which keeps
+// the <BANK>_users.txt and <BANK>_prepared.txt files the bank has always
+// used, and by a log store, which appends every change to a checksummed log
+// and folds it into a snapshot from time to time. The storetest package
+// checks that an implementation behaves as described here.
+package accountstore
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+// Account is one bank account.
+type Account struct {
+	ID       string
+	Username string
+	// PasswordHash is empty for accounts opened through the gateway,
+	// whose holders sign in there.
+	PasswordHash string
+	BankName     string
+	Balance      *pb.Money
+}
+
+// Hold is money reserved by a prepared debit or credit until the
+// transaction is posted or released. Only debit holds reduce the available
+// balance.
+type Hold struct {
+	Key           string // the leg's transaction key
+	TransactionID string // global transfer id
+	Operation     string // "debit" or "credit"
+	AccountID     string
+	Counterparty  string
+	Amount        *pb.Money
+	PreparedAt    time.Time
+	Deadline      time.Time
+}
+
+// Posting changes the balance of one account: a debit subtracts Amount, a
+// credit adds it. Key is the transaction key of the leg; the hold with the
+// same key, if any, goes away with the posting.
+type Posting struct {
+	Key       string
+	AccountID string
+	Operation string // "debit" or "credit"
+	Amount    *pb.Money
+}
+
+// Errors returned by Store methods; wrapped errors carry more detail.
+var (
+	ErrAccountNotFound   = errors.New("account not found")
+	ErrAccountExists     = errors.New("account already exists")
+	ErrAccountNotEmpty   = errors.New("account balance is not zero")
+	ErrAccountHeld       = errors.New("account has a transaction in progress")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrCurrencyMismatch  = errors.New("currency mismatch")
+)
+
+// Store holds the accounts of one bank. Every method is safe for concurrent
+// use, and a change has reached disk when the method returns.
+type Store interface {
+	// Get returns the account with id, or ErrAccountNotFound.
+	Get(id string) (Account, error)
+	// List returns every account, ordered by id.
+	List() ([]Account, error)
+	// Create adds an account, or fails with ErrAccountExists.
+	Create(acct Account) error
+	// Remove deletes an account. It fails with ErrAccountNotEmpty while
+	// the balance is not zero and with ErrAccountHeld while a hold is on
+	// the account.
+	Remove(id string) error
+	// Post applies p and drops the hold with p.Key on the same account in
+	// one step, crashes included: while the hold is there, p has not been
+	// applied. A debit that would leave the balance negative fails with
+	// ErrInsufficientFunds; an amount in another currency than the account
+	// fails with ErrCurrencyMismatch. Neither leaves any change behind.
+	Post(p Posting) error
+	// PutHold records h, replacing any hold with the same key on the same
+	// account. A key in use on another account is refused.
+	PutHold(h Hold) error
+	// ReleaseHold drops the hold with key. Releasing an unknown key is not
+	// an error.
+	ReleaseHold(key string) error
+	// Holds returns every hold, ordered by key.
+	Holds() ([]Hold, error)
+	// Close releases the store's files.
+	Close() error
+}
+
+// Kinds of store accepted by Open.
+const (
+	KindCSV = "csv"
+	KindLog = "log"
+)
+
+// Open opens the store of bankName in dir.
+func Open(kind, dir, bankName string) (Store, error) {
+	switch kind {
+	case KindCSV:
+		return OpenCSV(dir, bankName)
+	case KindLog:
+		return OpenLog(dir, bankName)
+	}
+	return nil, fmt.Errorf("unknown account store %q (want %q or %q)", kind, KindCSV, KindLog)
+}
+
+// applyPosting returns balance after p. It is shared by the stores so they
+// agree on the rules.
+func applyPosting(balance *pb.Money, p Posting) (*pb.Money, error) {
+	if balance.CurrencyCode != p.Amount.GetCurrencyCode() {
+		return nil, fmt.Errorf("%w: account %s is held in %s, not %s", ErrCurrencyMismatch, p.AccountID, balance.CurrencyCode, p.Amount.GetCurrencyCode())
+	}
+	var updated *pb.Money
+	var err error
+	switch p.Operation {
+	case "debit":
+		updated, err = money.Sub(balance, p.Amount)
+	case "credit":
+		updated, err = money.Add(balance, p.Amount)
+	default:
+		return nil, fmt.Errorf("unknown operation %q", p.Operation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot %s account %s: %v", p.Operation, p.AccountID, err)
+	}
+	if updated.MinorUnits < 0 {
+		return nil, fmt.Errorf("%w: debit of %s from account %s holding %s", ErrInsufficientFunds, money.Format(p.Amount), p.AccountID, money.Format(balance))
+	}
+	return updated, nil
+}
+
+// checkHoldKey refuses h if its key is in use on another account.
+func checkHoldKey(h Hold, holds map[string]Hold) error {
+	if old, ok := holds[h.Key]; ok && old.AccountID != h.AccountID {
+		return fmt.Errorf("hold %s is already on account %s", h.Key, old.AccountID)
+	}
+	return nil
+}
+
+// checkRemovable reports why acct cannot be removed, if it cannot.
+func checkRemovable(acct Account, holds map[string]Hold) error {
+	if acct.Balance.GetMinorUnits() != 0 {
+		return fmt.Errorf("%w: account %s holds %s", ErrAccountNotEmpty, acct.ID, money.Format(acct.Balance))
+	}
+	for _, h := range holds {
+		if h.AccountID == acct.ID {
+			return fmt.Errorf("%w: account %s, hold %s", ErrAccountHeld, acct.ID, h.Key)
+		}
+	}
+	return nil
+}
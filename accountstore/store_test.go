@@ -0,0 +1,19 @@
+package accountstore_test
+
+import (
+	"testing"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/accountstore/storetest"
+)
+
+func TestStores(t *testing.T) {
+	for _, kind := range []string{accountstore.KindCSV, accountstore.KindLog} {
+		t.Run(kind, func(t *testing.T) {
+			dir := t.TempDir()
+			storetest.Run(t, func() (accountstore.Store, error) {
+				return accountstore.Open(kind, dir, "TEST")
+			})
+		})
+	}
+}
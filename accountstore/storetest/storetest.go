@@ -0,0 +1,438 @@
+// Package storetest checks that an accountstore.Store behaves as the
+// interface describes. It is an ordinary package rather than a _test.go file
+// so that the tests of any store, including one added outside accountstore,
+// can run it.
+package storetest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+// errSkipped is returned by a check that does not apply to the store.
+var errSkipped = errors.New("not supported by this store")
+
+// Run checks the store returned by open, each check in a subtest of t.
+// open is called more than once and must return the same, initially empty,
+// store each time; the store from one call is closed before the next, so
+// that Run can check what survives a reopen. The checks build on each
+// other's accounts and holds, so one failure may be followed by others.
+func Run(t *testing.T, open func() (accountstore.Store, error)) {
+	s := &tester{open: open}
+	checks := []struct {
+		name string
+		fn   func() error
+	}{
+		{"empty", s.empty},
+		{"create", s.create},
+		{"holds", s.holds},
+		{"post", s.post},
+		{"rejected postings", s.rejected},
+		{"remove", s.remove},
+		{"reopen", s.reopen},
+		{"concurrent postings", s.concurrent},
+		{"snapshot", s.snapshot},
+	}
+	var err error
+	if s.store, err = open(); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { s.store.Close() }()
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			err := check.fn()
+			if errors.Is(err, errSkipped) {
+				t.Skip(err)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type tester struct {
+	open  func() (accountstore.Store, error)
+	store accountstore.Store
+}
+
+func account(id, minor int64) accountstore.Account {
+	return accountstore.Account{
+		ID:       fmt.Sprint(id),
+		Username: fmt.Sprintf("user%d", id),
+		BankName: "TEST",
+		Balance:  money.New(minor, money.DefaultCurrency),
+	}
+}
+
+func amount(minor int64) *pb.Money { return money.New(minor, money.DefaultCurrency) }
+
+func hold(key, accountID, op string, minor int64) accountstore.Hold {
+	now := time.Now().UTC().Truncate(time.Second)
+	return accountstore.Hold{
+		Key:           key,
+		TransactionID: "txn-" + key,
+		Operation:     op,
+		AccountID:     accountID,
+		Counterparty:  "other",
+		Amount:        amount(minor),
+		PreparedAt:    now,
+		Deadline:      now.Add(time.Minute),
+	}
+}
+
+// balance returns the balance of id in minor units.
+func (t *tester) balance(id string) (int64, error) {
+	acct, err := t.store.Get(id)
+	if err != nil {
+		return 0, err
+	}
+	return acct.Balance.GetMinorUnits(), nil
+}
+
+func (t *tester) wantBalance(id string, want int64) error {
+	got, err := t.balance(id)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("balance of %s is %d, want %d", id, got, want)
+	}
+	return nil
+}
+
+// holdKeys returns the keys of the store's holds in the order Holds gives.
+func (t *tester) holdKeys() ([]string, error) {
+	holds, err := t.store.Holds()
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(holds))
+	for i, h := range holds {
+		keys[i] = h.Key
+	}
+	return keys, nil
+}
+
+func (t *tester) wantHolds(want ...string) error {
+	got, err := t.holdKeys()
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		return fmt.Errorf("holds are %v, want %v", got, want)
+	}
+	return nil
+}
+
+func wantErr(err, target error) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("got error %v, want %v", err, target)
+	}
+	return nil
+}
+
+func (t *tester) empty() error {
+	accounts, err := t.store.List()
+	if err != nil {
+		return err
+	}
+	if len(accounts) != 0 {
+		return fmt.Errorf("new store lists %d accounts", len(accounts))
+	}
+	if err := t.wantHolds(); err != nil {
+		return err
+	}
+	_, err = t.store.Get("1")
+	return wantErr(err, accountstore.ErrAccountNotFound)
+}
+
+func (t *tester) create() error {
+	for _, acct := range []accountstore.Account{account(2, 5000), account(1, 10000), account(3, 0)} {
+		if err := t.store.Create(acct); err != nil {
+			return err
+		}
+	}
+	if err := wantErr(t.store.Create(account(1, 0)), accountstore.ErrAccountExists); err != nil {
+		return err
+	}
+	accounts, err := t.store.List()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, acct := range accounts {
+		ids = append(ids, acct.ID)
+	}
+	if fmt.Sprint(ids) != "[1 2 3]" {
+		return fmt.Errorf("List returned %v, want [1 2 3]", ids)
+	}
+	acct, err := t.store.Get("1")
+	if err != nil {
+		return err
+	}
+	if acct.Username != "user1" || acct.BankName != "TEST" || !money.Equal(acct.Balance, amount(10000)) {
+		return fmt.Errorf("Get returned %+v", acct)
+	}
+	// The returned balance is a copy.
+	acct.Balance.MinorUnits = 1
+	return t.wantBalance("1", 10000)
+}
+
+func (t *tester) holds() error {
+	if err := t.store.PutHold(hold("b", "1", "debit", 100)); err != nil {
+		return err
+	}
+	if err := t.store.PutHold(hold("a", "2", "credit", 200)); err != nil {
+		return err
+	}
+	if err := t.wantHolds("a", "b"); err != nil {
+		return err
+	}
+	// A hold with a key already in use replaces the old one.
+	if err := t.store.PutHold(hold("b", "1", "debit", 300)); err != nil {
+		return err
+	}
+	holds, err := t.store.Holds()
+	if err != nil {
+		return err
+	}
+	if len(holds) != 2 || !money.Equal(holds[1].Amount, amount(300)) {
+		return fmt.Errorf("holds after replacing b: %+v", holds)
+	}
+	want := hold("b", "1", "debit", 300)
+	got := holds[1]
+	if got.TransactionID != want.TransactionID || got.Operation != want.Operation || got.AccountID != want.AccountID ||
+		got.Counterparty != want.Counterparty || !got.PreparedAt.Equal(want.PreparedAt) || !got.Deadline.Equal(want.Deadline) {
+		return fmt.Errorf("hold b came back as %+v, want %+v", got, want)
+	}
+	// The key of a hold on one account cannot be reused on another.
+	if err := t.store.PutHold(hold("b", "2", "debit", 300)); err == nil {
+		return fmt.Errorf("hold b was moved from account 1 to account 2")
+	}
+	if err := t.store.ReleaseHold("a"); err != nil {
+		return err
+	}
+	if err := t.store.ReleaseHold("no-such-hold"); err != nil {
+		return fmt.Errorf("releasing an unknown key: %v", err)
+	}
+	// Holds do not change balances.
+	if err := t.wantBalance("1", 10000); err != nil {
+		return err
+	}
+	return t.wantHolds("b")
+}
+
+func (t *tester) post() error {
+	if err := t.store.Post(accountstore.Posting{Key: "b", AccountID: "1", Operation: "debit", Amount: amount(300)}); err != nil {
+		return err
+	}
+	if err := t.wantBalance("1", 9700); err != nil {
+		return err
+	}
+	if err := t.wantHolds(); err != nil {
+		return fmt.Errorf("after posting b: %v", err)
+	}
+	if err := t.store.PutHold(hold("c", "2", "credit", 250)); err != nil {
+		return err
+	}
+	if err := t.store.Post(accountstore.Posting{Key: "c", AccountID: "2", Operation: "credit", Amount: amount(250)}); err != nil {
+		return err
+	}
+	if err := t.wantBalance("2", 5250); err != nil {
+		return err
+	}
+	// A posting without a hold is fine too.
+	if err := t.store.Post(accountstore.Posting{Key: "d", AccountID: "2", Operation: "debit", Amount: amount(5250)}); err != nil {
+		return err
+	}
+	if err := t.wantBalance("2", 0); err != nil {
+		return err
+	}
+	return t.wantHolds()
+}
+
+func (t *tester) rejected() error {
+	if err := t.store.PutHold(hold("e", "1", "debit", 20000)); err != nil {
+		return err
+	}
+	err := t.store.Post(accountstore.Posting{Key: "e", AccountID: "1", Operation: "debit", Amount: amount(20000)})
+	if err := wantErr(err, accountstore.ErrInsufficientFunds); err != nil {
+		return err
+	}
+	err = t.store.Post(accountstore.Posting{Key: "e", AccountID: "1", Operation: "credit", Amount: money.New(100, "USD")})
+	if err := wantErr(err, accountstore.ErrCurrencyMismatch); err != nil {
+		return err
+	}
+	err = t.store.Post(accountstore.Posting{Key: "e", AccountID: "404", Operation: "credit", Amount: amount(100)})
+	if err := wantErr(err, accountstore.ErrAccountNotFound); err != nil {
+		return err
+	}
+	// None of them changed anything, the hold included.
+	if err := t.wantBalance("1", 9700); err != nil {
+		return err
+	}
+	if err := t.wantHolds("e"); err != nil {
+		return err
+	}
+	return t.store.ReleaseHold("e")
+}
+
+func (t *tester) remove() error {
+	if err := wantErr(t.store.Remove("1"), accountstore.ErrAccountNotEmpty); err != nil {
+		return err
+	}
+	if err := wantErr(t.store.Remove("404"), accountstore.ErrAccountNotFound); err != nil {
+		return err
+	}
+	if err := t.store.PutHold(hold("f", "3", "credit", 100)); err != nil {
+		return err
+	}
+	if err := wantErr(t.store.Remove("3"), accountstore.ErrAccountHeld); err != nil {
+		return err
+	}
+	if err := t.store.ReleaseHold("f"); err != nil {
+		return err
+	}
+	if err := t.store.Remove("3"); err != nil {
+		return err
+	}
+	if _, err := t.store.Get("3"); !errors.Is(err, accountstore.ErrAccountNotFound) {
+		return fmt.Errorf("Get of a removed account returned %v", err)
+	}
+	// The id can be used again.
+	if err := t.store.Create(account(3, 0)); err != nil {
+		return err
+	}
+	return t.store.Remove("3")
+}
+
+// reopen checks that everything so far was written down.
+func (t *tester) reopen() error {
+	if err := t.store.PutHold(hold("g", "1", "debit", 700)); err != nil {
+		return err
+	}
+	// A posting to another account under the key of a hold leaves the hold
+	// alone, when it is made and when it is replayed.
+	if err := t.store.Post(accountstore.Posting{Key: "g", AccountID: "2", Operation: "credit", Amount: amount(100)}); err != nil {
+		return err
+	}
+	if err := t.store.Post(accountstore.Posting{Key: "h", AccountID: "2", Operation: "debit", Amount: amount(100)}); err != nil {
+		return err
+	}
+	if err := t.wantHolds("g"); err != nil {
+		return err
+	}
+	if err := t.reopenStore(); err != nil {
+		return err
+	}
+	return t.wantState()
+}
+
+func (t *tester) reopenStore() error {
+	if err := t.store.Close(); err != nil {
+		return fmt.Errorf("close: %v", err)
+	}
+	store, err := t.open()
+	if err != nil {
+		return fmt.Errorf("reopen: %v", err)
+	}
+	t.store = store
+	return nil
+}
+
+// wantState checks the accounts and holds left by the checks up to reopen.
+func (t *tester) wantState() error {
+	accounts, err := t.store.List()
+	if err != nil {
+		return err
+	}
+	if len(accounts) != 2 {
+		return fmt.Errorf("%d accounts, want 2", len(accounts))
+	}
+	if err := t.wantBalance("1", 9700); err != nil {
+		return err
+	}
+	if err := t.wantBalance("2", 0); err != nil {
+		return err
+	}
+	return t.wantHolds("g")
+}
+
+// Goroutines and postings per goroutine used by concurrent.
+const workers, each = 8, 25
+
+// concurrent posts from many goroutines at once; none may be lost.
+func (t *tester) concurrent() error {
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				key := fmt.Sprintf("w%d-%d", w, i)
+				// Money moves from account 1 to account 2 one unit at a time.
+				if err := t.store.PutHold(hold(key, "1", "debit", 1)); err != nil {
+					errs <- err
+					return
+				}
+				if err := t.store.Post(accountstore.Posting{Key: key, AccountID: "1", Operation: "debit", Amount: amount(1)}); err != nil {
+					errs <- err
+					return
+				}
+				if err := t.store.Post(accountstore.Posting{Key: key + "-in", AccountID: "2", Operation: "credit", Amount: amount(1)}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	if err := <-errs; err != nil {
+		return err
+	}
+	moved := int64(workers * each)
+	if err := t.wantBalance("1", 9700-moved); err != nil {
+		return err
+	}
+	if err := t.wantBalance("2", moved); err != nil {
+		return err
+	}
+	return t.wantHolds("g")
+}
+
+// snapshot checks stores that can fold their history into a snapshot, such
+// as the log store, and is skipped for the others.
+func (t *tester) snapshot() error {
+	snap, ok := t.store.(interface{ Snapshot() error })
+	if !ok {
+		return errSkipped
+	}
+	if err := snap.Snapshot(); err != nil {
+		return err
+	}
+	// A change after the snapshot has to be replayed on top of it.
+	if err := t.store.ReleaseHold("g"); err != nil {
+		return err
+	}
+	if err := t.reopenStore(); err != nil {
+		return err
+	}
+	moved := int64(workers * each)
+	if err := t.wantBalance("1", 9700-moved); err != nil {
+		return err
+	}
+	if err := t.wantBalance("2", moved); err != nil {
+		return err
+	}
+	return t.wantHolds()
+}
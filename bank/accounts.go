@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/money"
+	"payment_gateway/password"
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+)
+
+var (
+	openingBalanceFlag = flag.String("opening-balance", "0.00", "Balance credited to accounts opened with OpenAccount, as a decimal amount; anything above zero lets any user mint money by opening accounts")
+	accountCurrency    = flag.String("currency", money.DefaultCurrency, "Currency of accounts opened with OpenAccount")
+)
+
+// openingBalance is what a new account starts with. It is set from the
+// flags at startup.
+var openingBalance *pb.Money
+
+// loadAccountPolicy parses the opening-balance flags.
+func loadAccountPolicy() error {
+	balance, err := money.ParseDecimal(*openingBalanceFlag, *accountCurrency)
+	if err != nil {
+		return fmt.Errorf("invalid -opening-balance %q: %v", *openingBalanceFlag, err)
+	}
+	if err := money.Validate(balance); err != nil {
+		return fmt.Errorf("invalid -opening-balance %q: %v", *openingBalanceFlag, err)
+	}
+	openingBalance = balance
+	return nil
+}
+
+// openAccountLock keeps two requests from picking the same new id.
+var openAccountLock sync.Mutex
+
+// accountIDPrefix starts the ids the bank chooses for new accounts.
+const accountIDPrefix = "ACC"
+
+// nextAccountID returns an id one above the highest ACC<n> in accounts.
+func nextAccountID(accounts []accountstore.Account) string {
+	highest := 0
+	for _, acct := range accounts {
+		if !strings.HasPrefix(acct.ID, accountIDPrefix) {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(acct.ID, accountIDPrefix)); err == nil && n > highest {
+			highest = n
+		}
+	}
+	return accountIDPrefix + strconv.Itoa(highest+1)
+}
+
+func accountResponse(acct accountstore.Account) *pb.AccountResponse {
+	return &pb.AccountResponse{AccountId: acct.ID, BankName: acct.BankName, Username: acct.Username, CurrencyCode: acct.Balance.GetCurrencyCode()}
+}
+
+func (b *bankServer) GetAccount(ctx context.Context, req *pb.AccountRequest) (*pb.AccountResponse, error) {
+	if err := checkServerActive(); err != nil {
+		return nil, err
+	}
+	log.Printf(ColorBlue+"[GetAccount] Called for account: %s in bank: %s"+ColorReset, req.AccountId, req.BankName)
+	acct, err := accountStore.Get(req.AccountId)
+	if errors.Is(err, accountstore.ErrAccountNotFound) {
+		msg := "Account not found"
+		log.Printf(ColorRed+"[GetAccount] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_FOUND, msg).Err()
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Could not read account: %v", err)
+		log.Printf(ColorRed+"[GetAccount] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+	}
+	log.Printf(ColorGreen+"[GetAccount] Account %s is held by %s"+ColorReset, req.AccountId, acct.Username)
+	return accountResponse(acct), nil
+}
+
+// VerifyAccountHolder checks the credentials of an account's holder before
+// the gateway links the account. Accounts opened with OpenAccount have no
+// bank password and are checked by holder alone.
+func (b *bankServer) VerifyAccountHolder(ctx context.Context, req *pb.VerifyAccountHolderRequest) (*pb.AccountResponse, error) {
+	if err := checkServerActive(); err != nil {
+		return nil, err
+	}
+	log.Printf(ColorBlue+"[VerifyAccountHolder] Called for account: %s in bank: %s by user %s"+ColorReset, req.AccountId, req.BankName, req.Username)
+	acct, err := accountStore.Get(req.AccountId)
+	if err != nil && !errors.Is(err, accountstore.ErrAccountNotFound) {
+		msg := fmt.Sprintf("Could not read account: %v", err)
+		log.Printf(ColorRed+"[VerifyAccountHolder] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+	}
+	holder := err == nil && acct.Username == req.Username
+	verified := holder && acct.PasswordHash == ""
+	if !verified {
+		// Check hashes even without a hash to compare to, so an unknown
+		// account, another holder and a wrong password take as long and get
+		// the same answer.
+		hash := ""
+		if holder {
+			hash = acct.PasswordHash
+		}
+		verified = password.Check(hash, req.Password)
+	}
+	if !verified {
+		msg := "Account holder or password does not match"
+		log.Printf(ColorRed+"[VerifyAccountHolder] Account %s: %s"+ColorReset, req.AccountId, msg)
+		return nil, payerr.New(pb.ErrorReason_INVALID_CREDENTIALS, msg).Err()
+	}
+	log.Printf(ColorGreen+"[VerifyAccountHolder] Verified %s as the holder of account %s"+ColorReset, req.Username, req.AccountId)
+	return accountResponse(acct), nil
+}
+
+// OpenAccount adds an account holding the opening balance, which is zero
+// unless the bank was started with -opening-balance: any signed-in user can
+// open accounts, so a new one is funded by transfers into it. Accounts
+// opened this way have no bank password; their holders sign in at the
+// gateway.
+func (b *bankServer) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.AccountResponse, error) {
+	if err := checkServerActive(); err != nil {
+		return nil, err
+	}
+	log.Printf(ColorBlue+"[OpenAccount] Called for user %s in bank: %s (requested id %q)"+ColorReset, req.Username, req.BankName, req.AccountId)
+	openAccountLock.Lock()
+	defer openAccountLock.Unlock()
+	accountID := req.AccountId
+	if accountID == "" {
+		accounts, err := accountStore.List()
+		if err != nil {
+			msg := fmt.Sprintf("Could not read accounts: %v", err)
+			log.Printf(ColorRed+"[OpenAccount] %s"+ColorReset, msg)
+			return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+		}
+		accountID = nextAccountID(accounts)
+	}
+	acct := accountstore.Account{ID: accountID, Username: req.Username, BankName: req.BankName, Balance: openingBalance}
+	err := accountStore.Create(acct)
+	if errors.Is(err, accountstore.ErrAccountExists) {
+		msg := fmt.Sprintf("Account %s already exists", accountID)
+		log.Printf(ColorRed+"[OpenAccount] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_EXISTS, msg).Err()
+	}
+	if err != nil {
+		msg := fmt.Sprintf("Failed to create account: %v", err)
+		log.Printf(ColorRed+"[OpenAccount] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+	}
+	// The account exists whether or not the ledger takes its opening; an
+	// account without entries gets one the next time the bank starts.
+	if err := ledgerOpen(accountID, openingBalance); err != nil {
+		log.Printf(ColorRed+"[OpenAccount] Warning: could not record the opening of %s in the ledger: %v"+ColorReset, accountID, err)
+	}
+	log.Printf(ColorGreen+"[OpenAccount] Opened account %s for %s with %s"+ColorReset, accountID, req.Username, money.Format(openingBalance))
+	return accountResponse(acct), nil
+}
+
+// CloseAccount removes an account whose balance is zero and which no
+// prepared transaction refers to.
+func (b *bankServer) CloseAccount(ctx context.Context, req *pb.AccountRequest) (*pb.CloseAccountResponse, error) {
+	if err := checkServerActive(); err != nil {
+		return nil, err
+	}
+	log.Printf(ColorBlue+"[CloseAccount] Called for account: %s in bank: %s"+ColorReset, req.AccountId, req.BankName)
+	// Prepares hold the account's lock from reading the account until their
+	// hold is placed, so none can start on the account while it is removed.
+	defer accountLocks.Lock(req.AccountId)()
+	acct, err := accountStore.Get(req.AccountId)
+	if err == nil {
+		err = accountStore.Remove(req.AccountId)
+	}
+	var reason pb.ErrorReason
+	var msg string
+	switch {
+	case err == nil:
+		log.Printf(ColorGreen+"[CloseAccount] Closed account %s of %s"+ColorReset, req.AccountId, acct.Username)
+		return &pb.CloseAccountResponse{Message: fmt.Sprintf("Account %s closed", req.AccountId)}, nil
+	case errors.Is(err, accountstore.ErrAccountNotFound):
+		reason, msg = pb.ErrorReason_ACCOUNT_NOT_FOUND, "Account not found"
+	case errors.Is(err, accountstore.ErrAccountHeld):
+		reason, msg = pb.ErrorReason_TRANSACTION_IN_PROGRESS, fmt.Sprintf("Account %s has a transfer in progress", req.AccountId)
+	case errors.Is(err, accountstore.ErrAccountNotEmpty):
+		reason, msg = pb.ErrorReason_ACCOUNT_NOT_EMPTY, fmt.Sprintf("Account %s still holds %s; transfer it out first", req.AccountId, money.Format(acct.Balance))
+	default:
+		reason, msg = pb.ErrorReason_STORAGE_FAILURE, fmt.Sprintf("Failed to remove account: %v", err)
+	}
+	log.Printf(ColorRed+"[CloseAccount] %s"+ColorReset, msg)
+	return nil, payerr.New(reason, msg).Err()
+}
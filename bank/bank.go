@@ -3,13 +3,12 @@ package main
 import (
 	"bufio"
 	"context"
-	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,7 +16,13 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 
+	"payment_gateway/accountstore"
+	"payment_gateway/money"
+	"payment_gateway/password"
+	"payment_gateway/payerr"
 	pb "payment_gateway/proto"
+	"payment_gateway/txnkey"
+	"payment_gateway/validate"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -39,31 +44,33 @@ func checkServerActive() error {
 	serverStatusMutex.RLock()
 	defer serverStatusMutex.RUnlock()
 	if !serverActive {
-		return fmt.Errorf("Bank is offline")
+		return payerr.New(pb.ErrorReason_BANK_UNAVAILABLE, "Bank is offline").Err()
 	}
 	return nil
 }
 
-var fileLock sync.Mutex
+// accountLocks serialises the operations on each account, so that a
+// prepare checks the available balance and places its hold with no other
+// prepare, commit or close of the account in between. Operations on
+// different accounts run in parallel.
+var accountLocks accountstore.AccountLocks
 
+// transactionsLog holds the keys of committed legs: those in the ledger,
+// and those in the <BANK>_transactions.txt file the bank kept before it had
+// a ledger.
 var transactionsLog = struct {
 	sync.RWMutex
 	log map[string]bool
 }{log: make(map[string]bool)}
 
+// loadTransactionsLog reads the keys of the old transactions file, if the
+// bank has one. The file is no longer written.
 func loadTransactionsLog(bankName string) {
 	filename := fmt.Sprintf("../%s_transactions.txt", bankName)
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		f, err := os.Create(filename)
-		if err != nil {
-			log.Printf(ColorRed+"Bank: Could not create transactions file: %v"+ColorReset, err)
-			return
-		}
-		f.Close()
-		log.Printf(ColorYellow+"Bank: Created new empty transactions file for bank '%s'."+ColorReset, bankName)
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
 		return
 	}
-	file, err := os.Open(filename)
 	if err != nil {
 		log.Printf(ColorRed+"Bank: Could not open transactions file: %v"+ColorReset, err)
 		return
@@ -83,68 +90,38 @@ func loadTransactionsLog(bankName string) {
 	}
 }
 
-func appendTransaction(bankName, txnID, senderAccount, receiverAccount, operation string, amount float64) error {
-	filename := fmt.Sprintf("../%s_transactions.txt", bankName)
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	line := fmt.Sprintf("%s,%s,%s,%.2f,%s\n", txnID, senderAccount, receiverAccount, amount, operation)
-	_, err = f.WriteString(line)
-	if err != nil {
-		return err
-	}
-	transactionsLog.Lock()
-	transactionsLog.log[txnID] = true
-	transactionsLog.Unlock()
-	return nil
-}
-
 func isTransactionProcessed(txnID string) bool {
 	transactionsLog.RLock()
 	defer transactionsLog.RUnlock()
 	return transactionsLog.log[txnID]
 }
 
-func ReadBankUsers(bankName string) ([][]string, error) {
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	file, err := os.Open(filename)
+// hashPlaintextPasswords replaces passwords stored in the clear in the
+// users file with salted hashes. It returns how many it replaced. Empty
+// passwords belong to accounts opened with OpenAccount and are left.
+func hashPlaintextPasswords(filename string) (int, error) {
+	records, err := accountstore.ReadUsersFile(filename)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	return reader.ReadAll()
-}
-
-func WriteBankUsers(bankName string, records [][]string) error {
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+	hashed := 0
+	for i, record := range records {
+		if i == 0 || len(record) < 3 || record[2] == "" || password.IsHash(record[2]) {
+			continue
+		}
+		hash, err := password.Hash(record[2])
+		if err != nil {
+			return 0, fmt.Errorf("account %s: %v", record[0], err)
+		}
+		records[i][2] = hash
+		hashed++
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	err = writer.WriteAll(records)
-	if err != nil {
-		return err
+	if hashed == 0 {
+		return 0, nil
 	}
-	writer.Flush()
-	return nil
+	return hashed, accountstore.WriteUsersFile(filename, records)
 }
 
-// preparedTransaction holds details of a prepared transaction.
-type preparedTransaction struct {
-	operation    string // "debit" or "credit"
-	accountId    string
-	counterparty string
-	amount       float64
-}
-
-var preparedMutex sync.RWMutex
-var preparedTransactions = make(map[string]preparedTransaction)
-
 type bankServer struct {
 	pb.UnimplementedBankServer
 }
@@ -157,123 +134,118 @@ func newBankServer() *bankServer {
 func (b *bankServer) ProcessTransaction(ctx context.Context, req *pb.TransactionRequest) (*pb.TransactionResponse, error) {
 	msg := "Use DebitAccount/CreditAccount or 2PC RPCs for transactions."
 	log.Printf(ColorYellow + msg + ColorReset)
-	return &pb.TransactionResponse{Success: false, Message: msg}, nil
+	return nil, payerr.New(pb.ErrorReason_UNSUPPORTED_OPERATION, msg).Err()
 }
 
 // -------- 2PC Methods for Debit --------
 
-// PrepareDebit: Reserve funds with composite key.
+// PrepareDebit: Place a hold on the funds under the leg's transaction key.
 func (b *bankServer) PrepareDebit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-debit"
-	log.Printf(ColorBlue+"[PrepareDebit] Received for key %s (Account: %s, Counterparty: %s, Amount: %.2f)"+ColorReset, key, req.AccountId, req.CounterpartyAccount, req.Amount)
+	key := txnkey.Format(req.Key)
+	log.Printf(ColorBlue+"[PrepareDebit] Received for key %s (Account: %s, Counterparty: %s, Amount: %s)"+ColorReset, key, req.AccountId, req.CounterpartyAccount, money.Format(req.Amount))
 	if err := checkServerActive(); err != nil {
-		return &pb.DebitCreditResponse{Success: false, Message: err.Error()}, nil
+		return nil, err
 	}
 	if req.AccountId == req.CounterpartyAccount {
 		msg := "Self-transfer not allowed."
 		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_SELF_TRANSFER, msg).Err()
 	}
 	if isTransactionProcessed(key) {
 		log.Printf(ColorCyan+"[PrepareDebit] Duplicate txn %s detected; already processed."+ColorReset, key)
 		return &pb.DebitCreditResponse{Success: true, Message: "Transaction already processed."}, nil
 	}
-	fileLock.Lock()
-	defer fileLock.Unlock()
-	bName := os.Getenv("BANK_NAME")
-	records, err := ReadBankUsers(bName)
+	defer accountLocks.Lock(req.AccountId)()
+	acct, err := accountStore.Get(req.AccountId)
+	if errors.Is(err, accountstore.ErrAccountNotFound) {
+		msg := "Sender account not found in prepare phase."
+		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_FOUND, msg).Err()
+	}
 	if err != nil {
-		msg := fmt.Sprintf("Failed to read bank file: %v", err)
+		msg := fmt.Sprintf("Failed to read account: %v", err)
 		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	found := false
-	var currentBalance float64
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-		if record[0] == req.AccountId {
-			found = true
-			currentBalance, _ = strconv.ParseFloat(record[4], 64)
-			break
+	balance := acct.Balance
+	if balance.CurrencyCode != req.Amount.CurrencyCode {
+		msg := fmt.Sprintf("Account %s is held in %s, not %s.", req.AccountId, balance.CurrencyCode, req.Amount.CurrencyCode)
+		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_CURRENCY_MISMATCH, msg).Err()
+	}
+	available, err := money.Sub(balance, heldAmount(req.AccountId, balance.CurrencyCode, key))
+	if err == nil {
+		var cmp int
+		cmp, err = money.Cmp(available, req.Amount)
+		if err == nil && cmp < 0 {
+			msg := fmt.Sprintf("Insufficient funds during prepare (available %s).", money.Format(available))
+			log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
+			return nil, payerr.New(pb.ErrorReason_INSUFFICIENT_FUNDS, msg).Err()
 		}
 	}
-	if !found {
-		msg := "Sender account not found in prepare phase."
+	if err != nil {
+		msg := fmt.Sprintf("Cannot debit account %s: %v", req.AccountId, err)
 		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, msg).Err()
 	}
-	if currentBalance < req.Amount {
-		msg := "Insufficient funds during prepare."
+	now := time.Now()
+	deadline, err := holdDeadline(now, req.PrepareDeadlineUnixMs)
+	if err != nil {
+		msg := err.Error()
 		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, msg).Err()
+	}
+	prep := preparedTransaction{
+		operation:     "debit",
+		accountId:     req.AccountId,
+		counterparty:  req.CounterpartyAccount,
+		amount:        req.Amount.MinorUnits,
+		currency:      req.Amount.CurrencyCode,
+		transactionId: req.Key.GlobalId,
+		preparedAt:    now,
+		deadline:      deadline,
+	}
+	err = putPrepared(key, prep)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to persist prepared debit: %v", err)
+		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	preparedMutex.Lock()
-	preparedTransactions[key] = preparedTransaction{
-		operation:    "debit",
-		accountId:    req.AccountId,
-		counterparty: req.CounterpartyAccount,
-		amount:       req.Amount,
+	if err := ledgerHold(key, prep); err != nil {
+		dropPreparedLocked(key, func(p preparedTransaction) bool { return p == prep })
+		msg := fmt.Sprintf("Failed to move the prepared debit into suspense: %v", err)
+		log.Printf(ColorRed+"[PrepareDebit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	preparedMutex.Unlock()
 	log.Printf(ColorGreen+"[PrepareDebit] Prepared txn %s successfully."+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Debit prepared successfully"}, nil
 }
 
 // CommitDebit: Deduct funds.
 func (b *bankServer) CommitDebit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-debit"
+	key := txnkey.Format(req.Key)
 	log.Printf(ColorBlue+"[CommitDebit] Received for key %s"+ColorReset, key)
-	preparedMutex.Lock()
-	prep, ok := preparedTransactions[key]
-	if !ok || prep.operation != "debit" ||
-		prep.accountId != req.AccountId ||
-		prep.counterparty != req.CounterpartyAccount ||
-		prep.amount != req.Amount {
-		preparedMutex.Unlock()
-		msg := "No matching prepared debit found."
-		log.Printf(ColorRed+"[CommitDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	delete(preparedTransactions, key)
-	preparedMutex.Unlock()
-
-	fileLock.Lock()
-	defer fileLock.Unlock()
-	bName := os.Getenv("BANK_NAME")
-	records, err := ReadBankUsers(bName)
-	if err != nil {
-		msg := fmt.Sprintf("Failed to read bank file: %v", err)
-		log.Printf(ColorRed+"[CommitDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	updated := false
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-		if record[0] == req.AccountId {
-			balance, _ := strconv.ParseFloat(record[4], 64)
-			balance -= req.Amount
-			records[i][4] = fmt.Sprintf("%.2f", balance)
-			updated = true
-			break
-		}
+	ok, err := commitPrepared(key, func(prep preparedTransaction) bool {
+		return prep.operation == "debit" &&
+			prep.accountId == req.AccountId &&
+			prep.counterparty == req.CounterpartyAccount &&
+			money.Equal(prep.money(), req.Amount)
+	})
+	if !ok && isTransactionProcessed(key) {
+		// The coordinator re-drives decisions after a crash; a repeated
+		// commit must be acknowledged rather than rejected.
+		log.Printf(ColorCyan+"[CommitDebit] Txn %s already committed."+ColorReset, key)
+		return &pb.DebitCreditResponse{Success: true, Message: "Debit already committed"}, nil
 	}
-	if !updated {
-		msg := "Sender account not found during commit."
+	if !ok {
+		msg := "No matching prepared debit found."
 		log.Printf(ColorRed+"[CommitDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_PREPARED_TRANSACTION_NOT_FOUND, msg).Err()
 	}
-	err = WriteBankUsers(bName, records)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to update bank file: %v", err)
+		msg := err.Error()
 		log.Printf(ColorRed+"[CommitDebit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	if err := appendTransaction(bName, key, req.AccountId, req.CounterpartyAccount, "debit", req.Amount); err != nil {
-		log.Printf(ColorRed+"[CommitDebit] Warning: %v"+ColorReset, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
 	log.Printf(ColorGreen+"[CommitDebit] Debit committed for txn %s"+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Debit committed successfully"}, nil
@@ -281,123 +253,103 @@ func (b *bankServer) CommitDebit(ctx context.Context, req *pb.DebitCreditRequest
 
 // AbortDebit: Cancel prepared debit.
 func (b *bankServer) AbortDebit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-debit"
+	key := txnkey.Format(req.Key)
 	log.Printf(ColorBlue+"[AbortDebit] Received for key %s"+ColorReset, key)
-	preparedMutex.Lock()
-	_, ok := preparedTransactions[key]
-	if ok {
-		delete(preparedTransactions, key)
-	}
-	preparedMutex.Unlock()
+	abortPrepared(key)
 	log.Printf(ColorYellow+"[AbortDebit] Debit aborted for txn %s"+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Debit aborted"}, nil
 }
 
 // PrepareCredit: Reserve credit.
 func (b *bankServer) PrepareCredit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-credit"
-	log.Printf(ColorBlue+"[PrepareCredit] Received for key %s"+ColorReset, key)
+	key := txnkey.Format(req.Key)
+	log.Printf(ColorBlue+"[PrepareCredit] Received for key %s (Amount: %s)"+ColorReset, key, money.Format(req.Amount))
 	if err := checkServerActive(); err != nil {
-		return &pb.DebitCreditResponse{Success: false, Message: err.Error()}, nil
+		return nil, err
 	}
 	if req.AccountId == req.CounterpartyAccount {
 		msg := "Self-transfer not allowed."
 		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_SELF_TRANSFER, msg).Err()
 	}
 	if isTransactionProcessed(key) {
 		log.Printf(ColorCyan+"[PrepareCredit] Duplicate txn %s detected; already processed."+ColorReset, key)
 		return &pb.DebitCreditResponse{Success: true, Message: "Transaction already processed."}, nil
 	}
-	fileLock.Lock()
-	defer fileLock.Unlock()
-	bName := os.Getenv("BANK_NAME")
-	records, err := ReadBankUsers(bName)
+	defer accountLocks.Lock(req.AccountId)()
+	acct, err := accountStore.Get(req.AccountId)
+	if errors.Is(err, accountstore.ErrAccountNotFound) {
+		msg := "Receiver account not found during prepare."
+		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_FOUND, msg).Err()
+	}
 	if err != nil {
-		msg := fmt.Sprintf("Failed to read bank file: %v", err)
+		msg := fmt.Sprintf("Failed to read account: %v", err)
 		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	found := false
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-		if record[0] == req.AccountId {
-			found = true
-			break
-		}
+	balance := acct.Balance
+	if balance.CurrencyCode != req.Amount.CurrencyCode {
+		msg := fmt.Sprintf("Account %s is held in %s, not %s.", req.AccountId, balance.CurrencyCode, req.Amount.CurrencyCode)
+		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_CURRENCY_MISMATCH, msg).Err()
 	}
-	if !found {
-		msg := "Receiver account not found during prepare."
+	if _, err := money.Add(balance, req.Amount); err != nil {
+		msg := fmt.Sprintf("Cannot credit account %s: %v", req.AccountId, err)
 		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, msg).Err()
 	}
-	preparedMutex.Lock()
-	preparedTransactions[key] = preparedTransaction{
-		operation:    "credit",
-		accountId:    req.AccountId,
-		counterparty: req.CounterpartyAccount,
-		amount:       req.Amount,
+	now := time.Now()
+	deadline, err := holdDeadline(now, req.PrepareDeadlineUnixMs)
+	if err != nil {
+		msg := err.Error()
+		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, msg).Err()
+	}
+	err = putPrepared(key, preparedTransaction{
+		operation:     "credit",
+		accountId:     req.AccountId,
+		counterparty:  req.CounterpartyAccount,
+		amount:        req.Amount.MinorUnits,
+		currency:      req.Amount.CurrencyCode,
+		transactionId: req.Key.GlobalId,
+		preparedAt:    now,
+		deadline:      deadline,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("Failed to persist prepared credit: %v", err)
+		log.Printf(ColorRed+"[PrepareCredit] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	preparedMutex.Unlock()
 	log.Printf(ColorGreen+"[PrepareCredit] Credit prepared for txn %s"+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Credit prepared successfully"}, nil
 }
 
 // CommitCredit: Add funds.
 func (b *bankServer) CommitCredit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-credit"
+	key := txnkey.Format(req.Key)
 	log.Printf(ColorBlue+"[CommitCredit] Received for key %s"+ColorReset, key)
-	preparedMutex.Lock()
-	prep, ok := preparedTransactions[key]
-	if !ok || prep.operation != "credit" ||
-		prep.accountId != req.AccountId ||
-		prep.counterparty != req.CounterpartyAccount ||
-		prep.amount != req.Amount {
-		preparedMutex.Unlock()
-		msg := "No matching prepared credit found."
-		log.Printf(ColorRed+"[CommitCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	delete(preparedTransactions, key)
-	preparedMutex.Unlock()
-
-	fileLock.Lock()
-	defer fileLock.Unlock()
-	bName := os.Getenv("BANK_NAME")
-	records, err := ReadBankUsers(bName)
-	if err != nil {
-		msg := fmt.Sprintf("Failed to read bank file: %v", err)
-		log.Printf(ColorRed+"[CommitCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	updated := false
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-		if record[0] == req.AccountId {
-			balance, _ := strconv.ParseFloat(record[4], 64)
-			balance += req.Amount
-			records[i][4] = fmt.Sprintf("%.2f", balance)
-			updated = true
-			break
-		}
+	ok, err := commitPrepared(key, func(prep preparedTransaction) bool {
+		return prep.operation == "credit" &&
+			prep.accountId == req.AccountId &&
+			prep.counterparty == req.CounterpartyAccount &&
+			money.Equal(prep.money(), req.Amount)
+	})
+	if !ok && isTransactionProcessed(key) {
+		// The coordinator re-drives decisions after a crash; a repeated
+		// commit must be acknowledged rather than rejected.
+		log.Printf(ColorCyan+"[CommitCredit] Txn %s already committed."+ColorReset, key)
+		return &pb.DebitCreditResponse{Success: true, Message: "Credit already committed"}, nil
 	}
-	if !updated {
-		msg := "Receiver account not found during commit."
+	if !ok {
+		msg := "No matching prepared credit found."
 		log.Printf(ColorRed+"[CommitCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_PREPARED_TRANSACTION_NOT_FOUND, msg).Err()
 	}
-	err = WriteBankUsers(bName, records)
 	if err != nil {
-		msg := fmt.Sprintf("Failed to update bank file: %v", err)
+		msg := err.Error()
 		log.Printf(ColorRed+"[CommitCredit] %s"+ColorReset, msg)
-		return &pb.DebitCreditResponse{Success: false, Message: msg}, nil
-	}
-	if err := appendTransaction(bName, key, req.CounterpartyAccount, req.AccountId, "credit", req.Amount); err != nil {
-		log.Printf(ColorRed+"[CommitCredit] Warning: %v"+ColorReset, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
 	log.Printf(ColorGreen+"[CommitCredit] Credit committed for txn %s"+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Credit committed successfully"}, nil
@@ -405,46 +357,45 @@ func (b *bankServer) CommitCredit(ctx context.Context, req *pb.DebitCreditReques
 
 // AbortCredit: Cancel prepared credit.
 func (b *bankServer) AbortCredit(ctx context.Context, req *pb.DebitCreditRequest) (*pb.DebitCreditResponse, error) {
-	key := req.TransactionId + "-credit"
+	key := txnkey.Format(req.Key)
 	log.Printf(ColorBlue+"[AbortCredit] Received for key %s"+ColorReset, key)
-	preparedMutex.Lock()
-	_, ok := preparedTransactions[key]
-	if ok {
-		delete(preparedTransactions, key)
-	}
-	preparedMutex.Unlock()
+	abortPrepared(key)
 	log.Printf(ColorYellow+"[AbortCredit] Credit aborted for txn %s"+ColorReset, key)
 	return &pb.DebitCreditResponse{Success: true, Message: "Credit aborted"}, nil
 }
 
 func (b *bankServer) GetBalance(ctx context.Context, req *pb.BalanceRequest) (*pb.BalanceResponse, error) {
 	if err := checkServerActive(); err != nil {
-		return &pb.BalanceResponse{Balance: 0, Message: err.Error()}, nil
+		return nil, err
 	}
 	log.Printf(ColorBlue+"[GetBalance] Called for account: %s in bank: %s"+ColorReset, req.AccountId, req.BankName)
-	records, err := ReadBankUsers(req.BankName)
+	acct, err := accountStore.Get(req.AccountId)
+	if errors.Is(err, accountstore.ErrAccountNotFound) {
+		msg := "Account not found"
+		log.Printf(ColorRed+"[GetBalance] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_FOUND, msg).Err()
+	}
 	if err != nil {
-		msg := fmt.Sprintf("Could not read bank file: %v", err)
+		msg := fmt.Sprintf("Could not read account: %v", err)
 		log.Printf(ColorRed+"[GetBalance] %s"+ColorReset, msg)
-		return &pb.BalanceResponse{Balance: 0, Message: msg}, nil
-	}
-	for _, record := range records {
-		if record[0] == req.AccountId {
-			balance, _ := strconv.ParseFloat(record[4], 64)
-			msg := fmt.Sprintf("Balance for account %s: %.2f", req.AccountId, balance)
-			log.Printf(ColorGreen+"[GetBalance] %s"+ColorReset, msg)
-			return &pb.BalanceResponse{Balance: balance, Message: msg}, nil
-		}
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	msg := "Account not found"
-	log.Printf(ColorRed+"[GetBalance] %s"+ColorReset, msg)
-	return &pb.BalanceResponse{Balance: 0, Message: msg}, nil
+	balance := acct.Balance
+	available, err := money.Sub(balance, heldAmount(req.AccountId, balance.CurrencyCode, ""))
+	if err != nil {
+		msg := fmt.Sprintf("Could not compute balance: %v", err)
+		log.Printf(ColorRed+"[GetBalance] %s"+ColorReset, msg)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+	}
+	msg := fmt.Sprintf("Balance for account %s: %s (available %s)", req.AccountId, money.Format(balance), money.Format(available))
+	log.Printf(ColorGreen+"[GetBalance] %s"+ColorReset, msg)
+	return &pb.BalanceResponse{Balance: balance, AvailableBalance: available, Message: msg}, nil
 }
 
 func monitorServerStatus() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
-		fmt.Print(ColorBlue + "Enter command (down/up): " + ColorReset)
+		fmt.Print(ColorBlue + "Enter command (down/up/stats/ledger): " + ColorReset)
 		if scanner.Scan() {
 			cmd := strings.ToLower(strings.TrimSpace(scanner.Text()))
 			if cmd == "down" {
@@ -457,8 +408,12 @@ func monitorServerStatus() {
 				serverActive = true
 				serverStatusMutex.Unlock()
 				log.Printf(ColorGreen + "[Monitor] Bank server now UP (simulated)" + ColorReset)
+			} else if cmd == "stats" {
+				logExpiryStats()
+			} else if cmd == "ledger" {
+				logTrialBalance()
 			} else {
-				log.Printf(ColorRed + "[Monitor] Unknown command. Use 'down' or 'up'." + ColorReset)
+				log.Printf(ColorRed + "[Monitor] Unknown command. Use 'down', 'up', 'stats' or 'ledger'." + ColorReset)
 			}
 		}
 	}
@@ -471,16 +426,29 @@ func promptInput(prompt string) string {
 	return strings.TrimSpace(text)
 }
 
-func registerBank(bankName string, port int) {
-	cert, err := tls.LoadX509KeyPair("../certs/client.pem", "../certs/client.key")
+// bankCertFiles returns the bank's certificate and key files.
+func bankCertFiles() (certFile, keyFile string) {
+	certFile, keyFile = *bankCert, *bankKey
+	if certFile == "" {
+		certFile = fmt.Sprintf("../certs/%s.pem", os.Getenv("BANK_NAME"))
+	}
+	if keyFile == "" {
+		keyFile = fmt.Sprintf("../certs/%s.key", os.Getenv("BANK_NAME"))
+	}
+	return certFile, keyFile
+}
+
+// dialGateway opens a TLS connection to the Payment Gateway, identifying
+// the bank with its own certificate.
+func dialGateway() (*grpc.ClientConn, error) {
+	certFile, keyFile := bankCertFiles()
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
 	if err != nil {
-		log.Printf("Bank: failed to load TLS credentials: %v", err)
-		return
+		return nil, fmt.Errorf("failed to load TLS credentials: %v", err)
 	}
 	caCert, err := os.ReadFile("../certs/ca.pem")
 	if err != nil {
-		log.Printf("Bank: failed to read CA certificate: %v", err)
-		return
+		return nil, fmt.Errorf("failed to read CA certificate: %v", err)
 	}
 	caCertPool := x509.NewCertPool()
 	caCertPool.AppendCertsFromPEM(caCert)
@@ -488,7 +456,11 @@ func registerBank(bankName string, port int) {
 		Certificates: []tls.Certificate{cert},
 		RootCAs:      caCertPool,
 	})
-	conn, err := grpc.Dial("localhost:50051", grpc.WithTransportCredentials(creds))
+	return grpc.Dial(*gatewayAddr, grpc.WithTransportCredentials(creds))
+}
+
+func registerBank(bankName string, port int) {
+	conn, err := dialGateway()
 	if err != nil {
 		log.Printf("Bank: failed to dial Payment Gateway: %v", err)
 		return
@@ -509,10 +481,25 @@ func registerBank(bankName string, port int) {
 	log.Printf("Bank: Registration successful: %s", resp.Message)
 }
 
+var (
+	gatewayAddr = flag.String("gateway", "localhost:50051", "Payment Gateway address")
+	// The gateway only accepts BankRegister from a certificate with
+	// CN=<bank name> and OU=bank. The key also signs ledger checkpoints.
+	bankCert  = flag.String("cert", "", "Certificate presented to the gateway (default ../certs/<bank>.pem)")
+	bankKey   = flag.String("key", "", "Key for -cert (default ../certs/<bank>.key)")
+	storeKind = flag.String("store", accountstore.KindLog, "Account store: \"log\" keeps accounts in memory with an append-only <bank>_accounts.log and snapshots, \"csv\" keeps <bank>_users.txt and <bank>_prepared.txt")
+)
+
 func main() {
 	bankNameFlag := flag.String("bank", "DefaultBank", "Name of the bank")
 	port := flag.Int("port", 50052, "Bank server port")
+	inDoubtAfter := flag.Duration("in-doubt-after", 10*time.Second, "Age after which a prepared transaction is resolved with the gateway")
+	resolveInterval := flag.Duration("resolve-interval", 5*time.Second, "How often in-doubt prepared transactions are checked")
+	reapInterval := flag.Duration("reap-interval", 5*time.Second, "How often expired holds are released")
 	flag.Parse()
+	if err := loadAccountPolicy(); err != nil {
+		log.Fatalf(ColorRed+"Bank: %v"+ColorReset, err)
+	}
 
 	bankName := *bankNameFlag
 	log.Printf(ColorCyan+"[Startup] Bank Server '%s' starting..."+ColorReset, bankName)
@@ -520,42 +507,59 @@ func main() {
 
 	loadTransactionsLog(bankName)
 
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		f, err := os.Create(filename)
-		if err != nil {
-			log.Fatalf(ColorRed+"Bank: Failed to create users file for bank '%s': %v"+ColorReset, bankName, err)
+	// Passwords are hashed in the users file before any store reads it, so
+	// none reach the log store in the clear.
+	usersFile := fmt.Sprintf("../%s_users.txt", bankName)
+	if _, err := os.Stat(usersFile); err == nil {
+		if hashed, err := hashPlaintextPasswords(usersFile); err != nil {
+			log.Fatalf(ColorRed+"Bank: Failed to hash passwords in %s: %v"+ColorReset, usersFile, err)
+		} else if hashed > 0 {
+			log.Printf(ColorYellow+"Bank: Replaced %d plaintext password(s) in %s with hashes."+ColorReset, hashed, usersFile)
 		}
-		defer f.Close()
-		f.WriteString("AccountId,username,password,bank_name,balance\n")
-		log.Printf(ColorYellow+"Bank: Created new users file for bank '%s'."+ColorReset, bankName)
+	}
+	if err := openAccountStore(bankName); err != nil {
+		log.Fatalf(ColorRed+"Bank: %v"+ColorReset, err)
+	}
+	defer accountStore.Close()
+	if err := openLedger(bankName); err != nil {
+		log.Fatalf(ColorRed+"Bank: Could not open the ledger: %v"+ColorReset, err)
+	}
+	loadLedgerSigner()
+	if err := reconcileLedger(); err != nil {
+		log.Fatalf(ColorRed+"Bank: Could not reconcile the ledger with the account store: %v"+ColorReset, err)
+	}
+	loadPreparedTransactions()
+
+	accounts, err := accountStore.List()
+	if err != nil {
+		log.Printf(ColorRed+"Bank: Error reading users for bank '%s': %v"+ColorReset, bankName, err)
+	} else if len(accounts) == 0 {
+		log.Printf(ColorYellow+"Bank: No registered users found for bank '%s'."+ColorReset, bankName)
 	} else {
-		records, err := ReadBankUsers(bankName)
-		if err != nil {
-			log.Printf(ColorRed+"Bank: Error reading users for bank '%s': %v"+ColorReset, bankName, err)
-		} else if len(records) <= 1 {
-			log.Printf(ColorYellow+"Bank: No registered users found for bank '%s'."+ColorReset, bankName)
-		} else {
-			log.Printf(ColorGreen+"Bank: Registered users for bank '%s':"+ColorReset, bankName)
-			for i, record := range records {
-				if i == 0 {
-					log.Printf(ColorBlue+"%s"+ColorReset, strings.Join(record, " | "))
-				} else {
-					log.Printf(ColorCyan+"%s"+ColorReset, strings.Join(record, " | "))
-				}
-			}
+		log.Printf(ColorGreen+"Bank: Registered users for bank '%s':"+ColorReset, bankName)
+		// Password hashes stay out of logs.
+		log.Printf(ColorBlue + "AccountId | username | bank_name | balance" + ColorReset)
+		for _, acct := range accounts {
+			log.Printf(ColorCyan+"%s | %s | %s | %s"+ColorReset, acct.ID, acct.Username, acct.BankName, money.Format(acct.Balance))
 		}
 	}
 
 	go monitorServerStatus()
 	registerBank(bankName, *port)
+	go resolveInDoubtTransactions(bankName, *inDoubtAfter, *resolveInterval)
+	go reapExpiredHolds(bankName, *reapInterval)
+	go checkpointLedger(*checkpointInterval)
 
 	log.Printf(ColorGreen+"Bank: Server for '%s' listening on port %d"+ColorReset, bankName, *port)
 	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", *port))
 	if err != nil {
 		log.Fatalf(ColorRed+"Bank: Failed to listen: %v"+ColorReset, err)
 	}
-	grpcServer := grpc.NewServer()
+	// Requests are validated before they reach the handlers; balance
+	// queries must name this bank.
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(validate.UnaryServerInterceptor(func(name string) bool {
+		return name == bankName
+	})))
 	bankSrv := newBankServer()
 	pb.RegisterBankServer(grpcServer, bankSrv)
 	if err := grpcServer.Serve(lis); err != nil {
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"crypto"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/journal"
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+// The ledger is a double-entry journal of every movement of money in the
+// bank, kept in ../<BANK>_ledger.txt in the format of the journal package:
+// each entry is a line whose debits equal its credits in each currency and
+// which carries the hash of the line before it. The balance of a ledger
+// account is its credits minus its debits, so customer accounts, which the
+// bank owes to their holders, have positive balances and the balances of
+// all ledger accounts add up to zero.
+//
+// Besides one ledger account per customer account the bank keeps three of
+// its own, named with an "@" that account ids cannot contain:
+//
+//   - @opening funds the balances accounts are opened with.
+//   - @suspense holds the money of prepared debits until the coordinator
+//     decides, so a customer's ledger balance is the available balance.
+//   - @clearing is what other banks owe this one through the gateway: a
+//     committed debit moves money from suspense to clearing, a committed
+//     credit from clearing to the receiver.
+//
+// A commit is written to the ledger before it is posted to the account
+// store, so after a crash the store can always be brought back in line
+// with the ledger; reconcileLedger does that at startup and then checks
+// that every balance in the store is what the ledger's postings add up to,
+// refusing to start the bank if one is not.
+//
+// Every -checkpoint-every entries, and every -checkpoint-interval while
+// there are entries since the last one, the bank signs a checkpoint with
+// its TLS key, so that verify_ledger can prove the history up to it was not
+// altered.
+const (
+	ledgerOpening  = "@opening"
+	ledgerSuspense = "@suspense"
+	ledgerClearing = "@clearing"
+)
+
+// Kinds of journal entry. The reference of an opening is the account id;
+// the others refer to a leg's transaction key.
+const (
+	entryOpening = "opening" // account opened, or found in the store without entries
+	entryHold    = "hold"    // debit prepared: account to suspense
+	entryRelease = "release" // prepared debit aborted or expired: suspense to account
+	entryDebit   = "debit"   // debit committed: suspense to clearing
+	entryCredit  = "credit"  // credit committed: clearing to account
+)
+
+var (
+	checkpointEvery    = flag.Int("checkpoint-every", 100, "Ledger entries between signed checkpoints")
+	checkpointInterval = flag.Duration("checkpoint-interval", time.Minute, "Longest time ledger entries wait for a signed checkpoint")
+)
+
+func debitOf(account string, amount *pb.Money) journal.Posting {
+	return journal.Posting{Account: account, Side: journal.SideDebit, Amount: amount}
+}
+
+func creditOf(account string, amount *pb.Money) journal.Posting {
+	return journal.Posting{Account: account, Side: journal.SideCredit, Amount: amount}
+}
+
+// ledgerKey names the balance of one ledger account in one currency.
+type ledgerKey struct {
+	account  string
+	currency string
+}
+
+// ledger is the open journal and the state its entries add up to.
+var ledger = struct {
+	sync.Mutex
+	bankName string
+	filename string
+	file     *os.File
+	size     int64         // length of the file up to the last whole entry
+	lastID   uint64        // id of the last entry written
+	lastHash string        // what the next entry links to
+	unsigned int           // entries since the last checkpoint
+	signer   crypto.Signer // signs checkpoints; nil if the bank has no key
+	failed   error         // why the ledger refuses entries, if it does
+	balances map[ledgerKey]int64
+	// suspense maps the key of every prepared debit whose money is in
+	// suspense to the account it came from.
+	suspense  map[string]journal.Posting
+	committed map[string]bool // keys of committed legs
+	entries   map[string]bool // accounts with at least one posting
+
+	syncMu sync.Mutex // serialises syncs of file
+	synced uint64     // id of the last entry known to be on disk
+}{
+	balances:  make(map[ledgerKey]int64),
+	suspense:  make(map[string]journal.Posting),
+	committed: make(map[string]bool),
+	entries:   make(map[string]bool),
+}
+
+// applyEntryLocked adds an entry to the balances and keeps track of the
+// money in suspense and of committed transaction keys.
+func applyEntryLocked(e journal.Entry) {
+	ledger.lastID = e.ID
+	if e.Kind == journal.KindCheckpoint {
+		ledger.unsigned = 0
+		return
+	}
+	ledger.unsigned++
+	for _, p := range e.Postings {
+		units := p.Amount.MinorUnits
+		if p.Side == journal.SideDebit {
+			units = -units
+		}
+		ledger.balances[ledgerKey{p.Account, p.Amount.CurrencyCode}] += units
+		ledger.entries[p.Account] = true
+	}
+	switch e.Kind {
+	case entryHold:
+		for _, p := range e.Postings {
+			if p.Side == journal.SideDebit {
+				ledger.suspense[e.Reference] = p
+			}
+		}
+	case entryRelease:
+		delete(ledger.suspense, e.Reference)
+	case entryDebit, entryCredit:
+		delete(ledger.suspense, e.Reference)
+		ledger.committed[e.Reference] = true
+		transactionsLog.Lock()
+		transactionsLog.log[e.Reference] = true
+		transactionsLog.Unlock()
+	}
+}
+
+// openLedger replays the ledger of bankName and opens it for appending. A
+// last entry torn by a crash is dropped; a broken link or any other damage
+// stops the bank, since the history could no longer be vouched for.
+// Signatures are not checked here; verify_ledger does that.
+func openLedger(bankName string) error {
+	ledger.Lock()
+	defer ledger.Unlock()
+	ledger.bankName = bankName
+	ledger.filename = fmt.Sprintf("../%s_ledger.txt", bankName)
+	f, err := os.OpenFile(ledger.filename, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	reader := journal.NewReader(f)
+	for {
+		e, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, journal.ErrTorn) {
+			log.Printf(ColorYellow+"Bank: Dropping torn entry at the end of %s"+ColorReset, ledger.filename)
+			break
+		}
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("%s: %v", ledger.filename, err)
+		}
+		applyEntryLocked(e)
+	}
+	if err := f.Truncate(reader.Offset()); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(reader.Offset(), io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+	ledger.file = f
+	ledger.size = reader.Offset()
+	ledger.lastHash = reader.LastHash()
+	ledger.synced = ledger.lastID
+	return nil
+}
+
+// loadLedgerSigner loads the key checkpoints are signed with: the bank's
+// TLS key, whose certificate auditors check them against. Without it the
+// ledger is still chained but not signed.
+func loadLedgerSigner() {
+	certFile, keyFile := bankCertFiles()
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err == nil {
+		signer, ok := cert.PrivateKey.(crypto.Signer)
+		if ok {
+			ledger.Lock()
+			ledger.signer = signer
+			ledger.Unlock()
+			return
+		}
+		err = fmt.Errorf("%s cannot sign", keyFile)
+	}
+	log.Printf(ColorYellow+"Bank: Ledger checkpoints will not be signed: %v"+ColorReset, err)
+}
+
+// appendLocked writes e after the last entry, linking it to it, and applies
+// it. The entry counts as soon as it is written, so that entries touching
+// the same key follow each other; it is not yet synced.
+func appendLocked(e journal.Entry) error {
+	if ledger.failed != nil {
+		return ledger.failed
+	}
+	if ledger.file == nil {
+		return fmt.Errorf("ledger is not open")
+	}
+	line, err := e.Encode()
+	if err != nil {
+		return err
+	}
+	if _, err := ledger.file.Write(line); err != nil {
+		// Drop whatever part of the entry got out, so that the next one
+		// does not follow a torn entry.
+		if ledger.file.Truncate(ledger.size) == nil {
+			ledger.file.Seek(ledger.size, io.SeekStart)
+		}
+		return err
+	}
+	ledger.size += int64(len(line))
+	ledger.lastHash = journal.Hash(line[:len(line)-1])
+	applyEntryLocked(e)
+	return nil
+}
+
+// checkpointLocked signs and appends a checkpoint covering every entry so
+// far. Without a signer it does nothing.
+func checkpointLocked() error {
+	if ledger.signer == nil {
+		return nil
+	}
+	e := journal.Entry{ID: ledger.lastID + 1, Time: time.Now(), Kind: journal.KindCheckpoint, Reference: ledger.bankName, Prev: ledger.lastHash}
+	if err := journal.Sign(&e, ledger.signer); err != nil {
+		return err
+	}
+	return appendLocked(e)
+}
+
+// postEntry appends a balanced entry to the ledger, followed by a
+// checkpoint if one is due, and returns once it is on disk. Entries written
+// while another waits for the disk are synced together with it. If a sync
+// fails the ledger cannot tell which entries reached the disk, so it
+// refuses further entries until the bank is restarted and the file is read
+// back.
+func postEntry(kind, reference string, postings ...journal.Posting) error {
+	ledger.Lock()
+	e := journal.Entry{ID: ledger.lastID + 1, Time: time.Now(), Kind: kind, Reference: reference, Postings: postings, Prev: ledger.lastHash}
+	if err := e.CheckBalanced(); err != nil {
+		ledger.Unlock()
+		return err
+	}
+	if err := appendLocked(e); err != nil {
+		ledger.Unlock()
+		return err
+	}
+	if ledger.unsigned >= *checkpointEvery {
+		if err := checkpointLocked(); err != nil {
+			log.Printf(ColorRed+"Bank: Could not write a ledger checkpoint: %v"+ColorReset, err)
+		}
+	}
+	ledger.Unlock()
+	return syncLedgerTo(e.ID)
+}
+
+// checkpointLedger signs a checkpoint every interval if entries were
+// written since the last one, so that a quiet bank's recent entries do not
+// stay unsigned.
+func checkpointLedger(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		ledger.Lock()
+		if ledger.unsigned == 0 || ledger.signer == nil {
+			ledger.Unlock()
+			continue
+		}
+		err := checkpointLocked()
+		id := ledger.lastID
+		ledger.Unlock()
+		if err == nil {
+			err = syncLedgerTo(id)
+		}
+		if err != nil {
+			log.Printf(ColorRed+"Bank: Could not write a ledger checkpoint: %v"+ColorReset, err)
+		}
+	}
+}
+
+// syncLedgerTo returns once the entry id is on disk.
+func syncLedgerTo(id uint64) error {
+	ledger.syncMu.Lock()
+	defer ledger.syncMu.Unlock()
+	if ledger.synced >= id {
+		return nil
+	}
+	ledger.Lock()
+	f, last, failed := ledger.file, ledger.lastID, ledger.failed
+	ledger.Unlock()
+	if failed != nil {
+		return failed
+	}
+	if err := f.Sync(); err != nil {
+		ledger.Lock()
+		ledger.failed = fmt.Errorf("%s could not be synced, restart the bank: %v", ledger.filename, err)
+		ledger.Unlock()
+		log.Printf(ColorRed+"Bank: %v"+ColorReset, ledger.failed)
+		return ledger.failed
+	}
+	ledger.synced = last
+	return nil
+}
+
+// ledgerHold moves the money of a prepared debit into suspense. A repeated
+// prepare of the same key leaves it there, or moves the difference if the
+// amount changed.
+func ledgerHold(key string, prep preparedTransaction) error {
+	ledger.Lock()
+	old, ok := ledger.suspense[key]
+	ledger.Unlock()
+	if ok && old.Account == prep.accountId && money.Equal(old.Amount, prep.money()) {
+		return nil
+	}
+	if ok {
+		if err := ledgerRelease(key); err != nil {
+			return err
+		}
+	}
+	return postEntry(entryHold, key, debitOf(prep.accountId, prep.money()), creditOf(ledgerSuspense, prep.money()))
+}
+
+// ledgerRelease returns the money of a prepared debit from suspense to its
+// account. Keys with nothing in suspense are ignored.
+func ledgerRelease(key string) error {
+	ledger.Lock()
+	held, ok := ledger.suspense[key]
+	ledger.Unlock()
+	if !ok {
+		return nil
+	}
+	return postEntry(entryRelease, key, debitOf(ledgerSuspense, held.Amount), creditOf(held.Account, held.Amount))
+}
+
+// ledgerCommit records a committed leg. A debit whose money is not in
+// suspense, which only happens if its hold entry was lost, is taken from
+// the account directly.
+func ledgerCommit(key string, prep preparedTransaction) error {
+	amount := prep.money()
+	if prep.operation == "credit" {
+		return postEntry(entryCredit, key, debitOf(ledgerClearing, amount), creditOf(prep.accountId, amount))
+	}
+	ledger.Lock()
+	_, held := ledger.suspense[key]
+	ledger.Unlock()
+	from := prep.accountId
+	if held {
+		from = ledgerSuspense
+	}
+	return postEntry(entryDebit, key, debitOf(from, amount), creditOf(ledgerClearing, amount))
+}
+
+// ledgerOpen records the balance an account was opened with.
+func ledgerOpen(accountID string, balance *pb.Money) error {
+	if balance.GetMinorUnits() == 0 {
+		return nil
+	}
+	return postEntry(entryOpening, accountID, debitOf(ledgerOpening, balance), creditOf(accountID, balance))
+}
+
+// ledgerBalance is the balance of a ledger account in currency.
+func ledgerBalance(account, currency string) int64 {
+	ledger.Lock()
+	defer ledger.Unlock()
+	return ledger.balances[ledgerKey{account, currency}]
+}
+
+// ledgerHeld is the money of account in suspense, in currency.
+func ledgerHeld(account, currency string) int64 {
+	ledger.Lock()
+	defer ledger.Unlock()
+	var held int64
+	for _, p := range ledger.suspense {
+		if p.Account == account && p.Amount.CurrencyCode == currency {
+			held += p.Amount.MinorUnits
+		}
+	}
+	return held
+}
+
+// expectedBalance is the balance the account store should show for acct:
+// its ledger balance plus its money in suspense.
+func expectedBalance(acct accountstore.Account) *pb.Money {
+	currency := acct.Balance.GetCurrencyCode()
+	return money.New(ledgerBalance(acct.ID, currency)+ledgerHeld(acct.ID, currency), currency)
+}
+
+// reconcileLedger finishes whatever a crash left between the ledger and the
+// account store, then checks them against each other. It runs at startup,
+// before any request is served.
+//
+// Accounts without ledger entries, which is every account the first time
+// the bank starts with a ledger, get an opening entry for their balance.
+// Holds are settled by their key alone. The store drops a hold in the same
+// step as it posts it, so a hold whose key the ledger has committed has not
+// been posted yet, and is posted now. A hold committed only in the
+// transactions file the bank kept before the ledger is dropped. Prepared
+// debits get their hold entry if it is missing, and money left in suspense
+// for a hold the store no longer has is released. Any difference left after
+// that is a discrepancy: verifyLedger reports it and the bank does not
+// start, since it would post on top of balances the ledger cannot vouch for.
+func reconcileLedger() error {
+	accounts, err := accountStore.List()
+	if err != nil {
+		return err
+	}
+	for _, acct := range accounts {
+		ledger.Lock()
+		known := ledger.entries[acct.ID]
+		ledger.Unlock()
+		if known {
+			continue
+		}
+		if err := ledgerOpen(acct.ID, acct.Balance); err != nil {
+			return err
+		}
+	}
+
+	holds, err := accountStore.Holds()
+	if err != nil {
+		return err
+	}
+	stillHeld := make(map[string]bool)
+	for _, h := range holds {
+		prep := preparedFromHold(h)
+		switch {
+		case committedInLedger(h.Key):
+			err := accountStore.Post(accountstore.Posting{Key: h.Key, AccountID: h.AccountID, Operation: h.Operation, Amount: h.Amount})
+			if err != nil {
+				return fmt.Errorf("could not post committed %s %s: %v", h.Operation, h.Key, err)
+			}
+			log.Printf(ColorYellow+"Bank: Posted %s %s, committed in the ledger before the bank stopped."+ColorReset, h.Operation, h.Key)
+		case isTransactionProcessed(h.Key):
+			if err := accountStore.ReleaseHold(h.Key); err != nil {
+				log.Printf(ColorRed+"Bank: Could not release committed hold %s: %v"+ColorReset, h.Key, err)
+			}
+		case h.Operation == "debit":
+			stillHeld[h.Key] = true
+			if err := ledgerHold(h.Key, prep); err != nil {
+				return err
+			}
+		}
+	}
+	ledger.Lock()
+	var orphaned []string
+	for key := range ledger.suspense {
+		if !stillHeld[key] {
+			orphaned = append(orphaned, key)
+		}
+	}
+	ledger.Unlock()
+	for _, key := range orphaned {
+		if err := ledgerRelease(key); err != nil {
+			return err
+		}
+	}
+
+	problems, err := verifyLedger()
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		log.Printf(ColorRed+"Bank: Ledger discrepancy: %s"+ColorReset, problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("the account store disagrees with the ledger in %d place(s)", len(problems))
+	}
+	return nil
+}
+
+// committedInLedger reports whether key was committed through the ledger
+// rather than only through the old transactions file.
+func committedInLedger(key string) bool {
+	ledger.Lock()
+	defer ledger.Unlock()
+	return ledger.committed[key]
+}
+
+// verifyLedger recomputes what the account store should hold from the
+// ledger's postings and lists every difference: accounts whose balance is
+// not their ledger balance plus their money in suspense, closed accounts
+// the ledger still owes money to, suspense that does not match the store's
+// prepared debits, and ledger accounts that do not add up to zero.
+func verifyLedger() ([]string, error) {
+	accounts, err := accountStore.List()
+	if err != nil {
+		return nil, err
+	}
+	holds, err := accountStore.Holds()
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	inStore := make(map[string]bool)
+	for _, acct := range accounts {
+		inStore[acct.ID] = true
+		if want := expectedBalance(acct); !money.Equal(want, acct.Balance) {
+			problems = append(problems, fmt.Sprintf("account %s holds %s but its postings add up to %s", acct.ID, money.Format(acct.Balance), money.Format(want)))
+		}
+	}
+	storeHeld := make(map[string]int64)
+	for _, h := range holds {
+		if h.Operation == "debit" {
+			storeHeld[h.Amount.GetCurrencyCode()] += h.Amount.GetMinorUnits()
+		}
+	}
+
+	ledger.Lock()
+	defer ledger.Unlock()
+	total := make(map[string]int64)
+	for k, units := range ledger.balances {
+		total[k.currency] += units
+		if !strings.HasPrefix(k.account, "@") && !inStore[k.account] && units != 0 {
+			problems = append(problems, fmt.Sprintf("closed account %s still has %s in the ledger", k.account, money.Format(money.New(units, k.currency))))
+		}
+	}
+	for currency, units := range total {
+		if units != 0 {
+			problems = append(problems, fmt.Sprintf("ledger accounts add up to %s instead of zero", money.Format(money.New(units, currency))))
+		}
+	}
+	currencies := make(map[string]bool)
+	for currency := range storeHeld {
+		currencies[currency] = true
+	}
+	for k := range ledger.balances {
+		if k.account == ledgerSuspense {
+			currencies[k.currency] = true
+		}
+	}
+	for currency := range currencies {
+		if suspense := ledger.balances[ledgerKey{ledgerSuspense, currency}]; suspense != storeHeld[currency] {
+			problems = append(problems, fmt.Sprintf("suspense holds %s but prepared debits add up to %s",
+				money.Format(money.New(suspense, currency)), money.Format(money.New(storeHeld[currency], currency))))
+		}
+	}
+	sort.Strings(problems)
+	return problems, nil
+}
+
+// logTrialBalance logs the balance of every ledger account and the result
+// of verifyLedger.
+func logTrialBalance() {
+	ledger.Lock()
+	keys := make([]ledgerKey, 0, len(ledger.balances))
+	for k := range ledger.balances {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].account != keys[j].account {
+			return keys[i].account < keys[j].account
+		}
+		return keys[i].currency < keys[j].currency
+	})
+	balances := make([]int64, len(keys))
+	for i, k := range keys {
+		balances[i] = ledger.balances[k]
+	}
+	lastID := ledger.lastID
+	ledger.Unlock()
+
+	log.Printf(ColorCyan+"[Ledger] %d entries; balances are credits minus debits:"+ColorReset, lastID)
+	for i, k := range keys {
+		log.Printf(ColorCyan+"[Ledger] %-12s %s"+ColorReset, k.account, money.Format(money.New(balances[i], k.currency)))
+	}
+	problems, err := verifyLedger()
+	if err != nil {
+		log.Printf(ColorRed+"[Ledger] Could not check the ledger: %v"+ColorReset, err)
+		return
+	}
+	if len(problems) == 0 {
+		log.Printf(ColorGreen + "[Ledger] Every balance matches its postings." + ColorReset)
+	}
+	for _, problem := range problems {
+		log.Printf(ColorRed+"[Ledger] %s"+ColorReset, problem)
+	}
+}
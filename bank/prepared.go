@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"payment_gateway/accountstore"
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+// preparedTransaction holds details of a prepared transaction.
+type preparedTransaction struct {
+	operation     string // "debit" or "credit"
+	accountId     string
+	counterparty  string
+	amount        int64 // minor units of currency
+	currency      string
+	transactionId string // global transfer id, used to ask the coordinator for the outcome
+	preparedAt    time.Time
+	deadline      time.Time // after this the hold may be expired (presumed abort)
+}
+
+func (prep preparedTransaction) money() *pb.Money {
+	return money.New(prep.amount, prep.currency)
+}
+
+var preparedMutex sync.RWMutex
+var preparedTransactions = make(map[string]preparedTransaction)
+
+// toHold is the account store's record of the prepared transaction key.
+func (prep preparedTransaction) toHold(key string) accountstore.Hold {
+	return accountstore.Hold{
+		Key:           key,
+		TransactionID: prep.transactionId,
+		Operation:     prep.operation,
+		AccountID:     prep.accountId,
+		Counterparty:  prep.counterparty,
+		Amount:        prep.money(),
+		PreparedAt:    prep.preparedAt,
+		Deadline:      prep.deadline,
+	}
+}
+
+func preparedFromHold(h accountstore.Hold) preparedTransaction {
+	return preparedTransaction{
+		operation:     h.Operation,
+		accountId:     h.AccountID,
+		counterparty:  h.Counterparty,
+		amount:        h.Amount.GetMinorUnits(),
+		currency:      h.Amount.GetCurrencyCode(),
+		transactionId: h.TransactionID,
+		preparedAt:    h.PreparedAt,
+		deadline:      h.Deadline,
+	}
+}
+
+// loadPreparedTransactions restores the votes this bank gave before it last
+// stopped from the holds in the account store. reconcileLedger has already
+// finished the holds whose key was committed; any it could not release are
+// released here.
+func loadPreparedTransactions() {
+	holds, err := accountStore.Holds()
+	if err != nil {
+		log.Printf(ColorRed+"Bank: Could not read prepared transactions: %v"+ColorReset, err)
+		return
+	}
+	preparedMutex.Lock()
+	defer preparedMutex.Unlock()
+	for _, h := range holds {
+		if isTransactionProcessed(h.Key) {
+			if err := accountStore.ReleaseHold(h.Key); err != nil {
+				log.Printf(ColorRed+"Bank: Could not release committed hold %s: %v"+ColorReset, h.Key, err)
+			}
+			continue
+		}
+		preparedTransactions[h.Key] = preparedFromHold(h)
+	}
+	if len(preparedTransactions) > 0 {
+		log.Printf(ColorYellow+"Bank: Reloaded %d in-doubt prepared transaction(s)."+ColorReset, len(preparedTransactions))
+	}
+}
+
+// putPrepared durably records a yes vote. A vote that cannot be persisted is
+// withdrawn so the bank never promises what it could forget.
+func putPrepared(key string, prep preparedTransaction) error {
+	preparedMutex.Lock()
+	defer preparedMutex.Unlock()
+	if err := accountStore.PutHold(prep.toHold(key)); err != nil {
+		return err
+	}
+	preparedTransactions[key] = prep
+	return nil
+}
+
+// takePrepared removes the record for key if match accepts it, so exactly
+// one caller goes on to apply it.
+func takePrepared(key string, match func(preparedTransaction) bool) (preparedTransaction, bool) {
+	preparedMutex.Lock()
+	defer preparedMutex.Unlock()
+	prep, ok := preparedTransactions[key]
+	if !ok || !match(prep) {
+		return preparedTransaction{}, false
+	}
+	delete(preparedTransactions, key)
+	return prep, true
+}
+
+func restorePrepared(key string, prep preparedTransaction) {
+	preparedMutex.Lock()
+	preparedTransactions[key] = prep
+	preparedMutex.Unlock()
+}
+
+// forgetPrepared releases the hold of a record already taken or deleted,
+// and returns the money of a prepared debit from suspense. A hold the store
+// could not release keeps its money in suspense; the next start releases
+// both.
+func forgetPrepared(key string) {
+	if err := accountStore.ReleaseHold(key); err != nil {
+		log.Printf(ColorRed+"Bank: Warning: could not release hold %s: %v"+ColorReset, key, err)
+		return
+	}
+	if err := ledgerRelease(key); err != nil {
+		log.Printf(ColorRed+"Bank: Warning: could not release %s from suspense: %v"+ColorReset, key, err)
+	}
+}
+
+// abortPrepared drops the record for key, if any, and releases its hold.
+// Like commitPrepared it takes the record under the account's lock, so of
+// an abort and a commit racing for the same record only one applies it.
+func abortPrepared(key string) {
+	preparedMutex.RLock()
+	prep, ok := preparedTransactions[key]
+	preparedMutex.RUnlock()
+	if !ok {
+		return
+	}
+	defer accountLocks.Lock(prep.accountId)()
+	dropPreparedLocked(key, func(p preparedTransaction) bool { return p.accountId == prep.accountId })
+}
+
+// dropPreparedLocked takes the record for key if match accepts it and
+// releases its hold. The caller holds the lock of the record's account.
+func dropPreparedLocked(key string, match func(preparedTransaction) bool) bool {
+	if _, ok := takePrepared(key, match); !ok {
+		return false
+	}
+	forgetPrepared(key)
+	return true
+}
+
+// heldAmount is the total reserved on accountId by prepared debits other
+// than excludeKey. Prepared debits are the holds: they reduce the available
+// balance until commit turns them into a posting or abort releases them.
+// Holds are always in the account's currency, which PrepareDebit checks.
+func heldAmount(accountId, currency, excludeKey string) *pb.Money {
+	preparedMutex.RLock()
+	defer preparedMutex.RUnlock()
+	held := money.Zero(currency)
+	for key, prep := range preparedTransactions {
+		if key != excludeKey && prep.operation == "debit" && prep.accountId == accountId {
+			held.MinorUnits += prep.amount
+		}
+	}
+	return held
+}
+
+// commitPrepared takes the prepared record for key, records the commit in
+// the ledger and posts it to the account store, which drops the hold in the
+// same step. Both happen under the account's lock so a concurrent prepare
+// never sees the hold released before the balance is reduced. Once the
+// ledger has the commit it stands: if the posting then fails, the hold
+// stays in the store and the next start posts it.
+func commitPrepared(key string, match func(preparedTransaction) bool) (bool, error) {
+	preparedMutex.RLock()
+	prep, ok := preparedTransactions[key]
+	preparedMutex.RUnlock()
+	if !ok {
+		return false, nil
+	}
+	// The record cannot move to another account; match checks it again
+	// once the lock is held.
+	defer accountLocks.Lock(prep.accountId)()
+	prep, ok = takePrepared(key, match)
+	if !ok {
+		return false, nil
+	}
+	if err := ledgerCommit(key, prep); err != nil {
+		restorePrepared(key, prep)
+		return true, fmt.Errorf("Failed to record %s of account %s in the ledger: %v", prep.operation, prep.accountId, err)
+	}
+	err := accountStore.Post(accountstore.Posting{
+		Key:       key,
+		AccountID: prep.accountId,
+		Operation: prep.operation,
+		Amount:    prep.money(),
+	})
+	if errors.Is(err, accountstore.ErrAccountNotFound) {
+		if prep.operation == "debit" {
+			err = fmt.Errorf("Sender account not found during commit.")
+		} else {
+			err = fmt.Errorf("Receiver account not found during commit.")
+		}
+	} else if err != nil {
+		err = fmt.Errorf("Failed to apply %s to account %s: %v; the ledger has it and the next start posts it", prep.operation, prep.accountId, err)
+	}
+	return true, err
+}
+
+// resolveInDoubtTransactions periodically asks the gateway for the outcome
+// of prepared transactions that have waited longer than inDoubtAfter and
+// finishes them locally, so a lost commit or abort message does not leave
+// the vote hanging.
+func resolveInDoubtTransactions(bankName string, inDoubtAfter, interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		inDoubt := make(map[string]preparedTransaction)
+		preparedMutex.RLock()
+		for key, prep := range preparedTransactions {
+			if time.Since(prep.preparedAt) >= inDoubtAfter {
+				inDoubt[key] = prep
+			}
+		}
+		preparedMutex.RUnlock()
+		if len(inDoubt) == 0 {
+			continue
+		}
+		conn, err := dialGateway()
+		if err != nil {
+			log.Printf(ColorRed+"[InDoubt] Cannot reach Payment Gateway: %v"+ColorReset, err)
+			continue
+		}
+		pgClient := pb.NewPaymentGatewayClient(conn)
+		for key, prep := range inDoubt {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			resp, err := pgClient.GetTransactionDecision(ctx, &pb.TransactionDecisionRequest{
+				TransactionId: prep.transactionId,
+				BankName:      bankName,
+			})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"[InDoubt] Decision query for %s failed: %v"+ColorReset, key, err)
+				continue
+			}
+			resolvePrepared(key, prep, resp.Decision)
+		}
+		conn.Close()
+	}
+}
+
+func resolvePrepared(key string, prep preparedTransaction, decision pb.TransactionDecision) {
+	switch decision {
+	case pb.TransactionDecision_DECISION_COMMIT:
+		found, err := commitPrepared(key, func(p preparedTransaction) bool { return p == prep })
+		if !found {
+			return
+		}
+		if err != nil {
+			log.Printf(ColorRed+"[InDoubt] Commit of %s failed: %v"+ColorReset, key, err)
+			return
+		}
+		log.Printf(ColorGreen+"[InDoubt] Txn %s committed on coordinator's decision."+ColorReset, key)
+	case pb.TransactionDecision_DECISION_ABORT:
+		unlock := accountLocks.Lock(prep.accountId)
+		dropped := dropPreparedLocked(key, func(p preparedTransaction) bool { return p == prep })
+		unlock()
+		if !dropped {
+			return
+		}
+		log.Printf(ColorYellow+"[InDoubt] Txn %s aborted on coordinator's decision."+ColorReset, key)
+	default:
+		log.Printf(ColorCyan+"[InDoubt] Txn %s still undecided (%s)."+ColorReset, key, decision)
+	}
+}
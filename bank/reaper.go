@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+var (
+	maxHold   = flag.Duration("max-hold", 2*time.Minute, "Longest prepare deadline the bank accepts from the gateway")
+	clockSkew = flag.Duration("clock-skew", 5*time.Second, "How long past its deadline a hold is kept before it is presumed aborted, to allow for the gateway's clock")
+)
+
+// holdDeadline picks the expiry for a new hold: the coordinator's deadline,
+// or this bank's maximum hold time if it sent none. A deadline beyond the
+// maximum is refused rather than shortened, since the coordinator may
+// decide to commit at any point before the deadline it sent.
+func holdDeadline(now time.Time, deadlineUnixMs int64) (time.Time, error) {
+	limit := now.Add(*maxHold)
+	if deadlineUnixMs <= 0 {
+		return limit, nil
+	}
+	deadline := time.UnixMilli(deadlineUnixMs)
+	if deadline.After(limit) {
+		return time.Time{}, fmt.Errorf("prepare deadline %s is more than %s away; this bank holds funds for at most %s",
+			deadline.Format(time.RFC3339), *maxHold, *maxHold)
+	}
+	if !deadline.After(now) {
+		return time.Time{}, fmt.Errorf("prepare deadline %s has already passed", deadline.Format(time.RFC3339))
+	}
+	return deadline, nil
+}
+
+// expiryStats counts holds released by the reaper.
+var expiryStats = struct {
+	sync.Mutex
+	count   int
+	amounts map[string]int64 // expired debit minor units per currency
+	last    time.Time
+}{amounts: make(map[string]int64)}
+
+func recordExpiry(prep preparedTransaction) (int, string) {
+	expiryStats.Lock()
+	defer expiryStats.Unlock()
+	expiryStats.count++
+	if prep.operation == "debit" {
+		expiryStats.amounts[prep.currency] += prep.amount
+	}
+	expiryStats.last = time.Now()
+	return expiryStats.count, expiredAmountsLocked()
+}
+
+func expiredAmountsLocked() string {
+	if len(expiryStats.amounts) == 0 {
+		return "0"
+	}
+	var parts []string
+	for currency, minor := range expiryStats.amounts {
+		parts = append(parts, money.Format(money.New(minor, currency)))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+func logExpiryStats() {
+	expiryStats.Lock()
+	defer expiryStats.Unlock()
+	preparedMutex.RLock()
+	pending := len(preparedTransactions)
+	preparedMutex.RUnlock()
+	last := "never"
+	if !expiryStats.last.IsZero() {
+		last = expiryStats.last.Format(time.RFC3339)
+	}
+	log.Printf(ColorCyan+"[Reaper] Prepared: %d, expired holds: %d, expired debit amount: %s, last expiry: %s"+ColorReset,
+		pending, expiryStats.count, expiredAmountsLocked(), last)
+}
+
+// reapExpiredHolds releases prepared records whose deadline has passed.
+// The policy is presumed abort: the gateway never decides commit after the
+// deadline, which holdDeadline keeps within -max-hold, so once the deadline
+// and the -clock-skew margin have passed the hold is released whatever the
+// gateway answers, and whether or not it can be reached. The gateway is
+// still asked, so that a commit it decided in time is applied rather than
+// waiting for its CommitDebit/CommitCredit.
+func reapExpiredHolds(bankName string, interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		now := time.Now()
+		expired := make(map[string]preparedTransaction)
+		preparedMutex.RLock()
+		for key, prep := range preparedTransactions {
+			if now.After(prep.deadline) {
+				expired[key] = prep
+			}
+		}
+		preparedMutex.RUnlock()
+		if len(expired) == 0 {
+			continue
+		}
+		var pgClient pb.PaymentGatewayClient
+		conn, err := dialGateway()
+		if err == nil {
+			pgClient = pb.NewPaymentGatewayClient(conn)
+		}
+		for key, prep := range expired {
+			decision := pb.TransactionDecision_DECISION_UNKNOWN
+			if pgClient != nil {
+				ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+				resp, err := pgClient.GetTransactionDecision(ctx, &pb.TransactionDecisionRequest{
+					TransactionId: prep.transactionId,
+					BankName:      bankName,
+				})
+				cancel()
+				if err == nil {
+					decision = resp.Decision
+				}
+			}
+			switch {
+			case decision == pb.TransactionDecision_DECISION_COMMIT:
+				resolvePrepared(key, prep, decision)
+			case now.After(prep.deadline.Add(*clockSkew)):
+				expireHold(bankName, key, prep, decision)
+			}
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
+
+func expireHold(bankName, key string, prep preparedTransaction, decision pb.TransactionDecision) {
+	unlock := accountLocks.Lock(prep.accountId)
+	dropped := dropPreparedLocked(key, func(p preparedTransaction) bool { return p == prep })
+	unlock()
+	if !dropped {
+		return
+	}
+	count, amount := recordExpiry(prep)
+	log.Printf(ColorYellow+"[Reaper] Expired %s hold %s (Account: %s, Amount: %s, prepared %s, deadline %s, coordinator: %s); presumed aborted. Total expired: %d (%s)"+ColorReset,
+		prep.operation, key, prep.accountId, money.Format(prep.money()),
+		prep.preparedAt.Format(time.RFC3339), prep.deadline.Format(time.RFC3339), decision, count, amount)
+}
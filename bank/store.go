@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"payment_gateway/accountstore"
+)
+
+// accountStore keeps this bank's accounts and holds. It is opened at
+// startup with the kind chosen by -store.
+var accountStore accountstore.Store
+
+// openAccountStore opens the account store in the parent directory, where
+// the other data files live. A log store that is still empty takes over
+// the accounts and holds of the CSV files, so a bank can switch to it
+// without losing anything; the CSV files are left as they were and are not
+// updated after that.
+func openAccountStore(bankName string) error {
+	store, err := accountstore.Open(*storeKind, "..", bankName)
+	if err != nil {
+		return fmt.Errorf("could not open %s account store: %v", *storeKind, err)
+	}
+	accountStore = store
+	if *storeKind == accountstore.KindCSV {
+		return nil
+	}
+	accounts, err := store.List()
+	if err != nil {
+		return err
+	}
+	holds, err := store.Holds()
+	if err != nil {
+		return err
+	}
+	if len(accounts) > 0 || len(holds) > 0 {
+		return nil
+	}
+	if _, err := os.Stat(fmt.Sprintf("../%s_users.txt", bankName)); err != nil {
+		return nil
+	}
+	imported, held, err := importAccounts(bankName)
+	if err != nil {
+		// Whatever was imported would stop the next start from importing
+		// again, so the store has to be cleared by hand.
+		return fmt.Errorf("could not import the CSV account files: %v; remove ../%s_accounts.log and start again", err, bankName)
+	}
+	if imported > 0 || held > 0 {
+		log.Printf(ColorYellow+"Bank: Imported %d account(s) and %d hold(s) from the CSV files into the %s store; those files are no longer updated."+ColorReset,
+			imported, held, *storeKind)
+	}
+	return nil
+}
+
+// importAccounts copies the accounts and holds of the CSV store into
+// accountStore.
+func importAccounts(bankName string) (int, int, error) {
+	csvStore, err := accountstore.OpenCSV("..", bankName)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer csvStore.Close()
+	accounts, err := csvStore.List()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, acct := range accounts {
+		if err := accountStore.Create(acct); err != nil {
+			return 0, 0, err
+		}
+	}
+	holds, err := csvStore.Holds()
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, h := range holds {
+		if err := accountStore.PutHold(h); err != nil {
+			return 0, 0, err
+		}
+	}
+	return len(accounts), len(holds), nil
+}
@@ -5,23 +5,25 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"payment_gateway/money"
+	"payment_gateway/payerr"
 	pb "payment_gateway/proto"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -43,7 +45,7 @@ func addToOfflineQueue(req *pb.TransferRequest) {
 	log.Printf(ColorYellow+"Client: Txn %s queued for offline processing."+ColorReset, req.TransactionId)
 }
 
-func processOfflineQueue(client pb.PaymentGatewayClient, token string) {
+func processOfflineQueue(client pb.PaymentGatewayClient, sess *session) {
 	for {
 		time.Sleep(10 * time.Second)
 		offlineQueueMutex.Lock()
@@ -57,13 +59,13 @@ func processOfflineQueue(client pb.PaymentGatewayClient, token string) {
 
 		log.Printf(ColorBlue+"Client: Retrying %d offline txn(s)..."+ColorReset, len(pending))
 		for _, req := range pending {
-			md := metadata.New(map[string]string{"authorization": token})
-			authCtx := metadata.NewOutgoingContext(context.Background(), md)
-			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
-			resp, err := client.TransferMoney(ctx, req)
+			ctx, cancel := context.WithTimeout(sess.authContext(), 5*time.Second)
+			_, err := client.TransferMoney(ctx, req)
 			cancel()
-			if err != nil || !resp.Success {
-				log.Printf(ColorRed+"Client: Offline txn %s failed: %v"+ColorReset, req.TransactionId, err)
+			if err != nil && !shouldRetry(err) {
+				log.Printf(ColorRed+"Client: Offline txn %s dropped: %s"+ColorReset, req.TransactionId, describeError(err))
+			} else if err != nil {
+				log.Printf(ColorRed+"Client: Offline txn %s failed: %s"+ColorReset, req.TransactionId, describeError(err))
 				addToOfflineQueue(req)
 			} else {
 				log.Printf(ColorGreen+"Client: Offline txn %s processed successfully."+ColorReset, req.TransactionId)
@@ -72,83 +74,175 @@ func processOfflineQueue(client pb.PaymentGatewayClient, token string) {
 	}
 }
 
-// WriteBankUsers writes CSV records to the bank's user file.
-func WriteBankUsers(bankName string, records [][]string) error {
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
+// shouldRetry reports whether a transfer that failed with err should be
+// queued and sent again. An expired token is renewed before the next try.
+func shouldRetry(err error) bool {
+	return payerr.Retryable(err) || payerr.Reason(err) == pb.ErrorReason_TOKEN_EXPIRED
+}
+
+// session holds the gateway token and renews it shortly before it expires.
+type session struct {
+	mu      sync.Mutex
+	client  pb.PaymentGatewayClient
+	token   string
+	expires time.Time
+}
+
+func (s *session) set(resp *pb.AuthResponse) {
+	s.token = resp.Token
+	s.expires = time.UnixMilli(resp.ExpiresUnixMs)
+}
+
+// authContext returns a context carrying a current token. If the token
+// expires within a minute it is refreshed first; on failure the old token
+// is used and the gateway's error is reported by the call.
+func (s *session) authContext() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Until(s.expires) < time.Minute {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		resp, err := s.client.RefreshToken(ctx, &pb.RefreshTokenRequest{Token: s.token})
+		cancel()
+		if err != nil {
+			log.Printf(ColorRed+"Client: Token refresh failed: %s"+ColorReset, describeError(err))
+		} else {
+			s.set(resp)
+			log.Printf(ColorCyan+"Client: Token refreshed; valid until %s"+ColorReset, s.expires.Format(time.RFC3339))
+		}
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	err = writer.WriteAll(records)
-	if err != nil {
-		return err
+	md := metadata.New(map[string]string{"authorization": s.token})
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+// logout revokes the session's token at the gateway.
+func (s *session) logout() {
+	ctx, cancel := context.WithTimeout(s.authContext(), 5*time.Second)
+	defer cancel()
+	if _, err := s.client.Logout(ctx, &pb.LogoutRequest{}); err != nil {
+		log.Printf(ColorRed+"Client: Logout failed: %s"+ColorReset, describeError(err))
+		return
 	}
-	writer.Flush()
-	return nil
+	log.Printf(ColorBlue + "Client: Logged out." + ColorReset)
 }
 
-// ReadBankUsers reads CSV records from the bank's user file.
-func ReadBankUsers(bankName string) ([][]string, error) {
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
+// describeError renders a gateway error with its reason, e.g.
+// "INSUFFICIENT_FUNDS at ICICI: ...".
+func describeError(err error) string {
+	failure := payerr.From(err)
+	if failure.Reason == pb.ErrorReason_ERROR_REASON_UNSPECIFIED {
+		return fmt.Sprintf("%s: %s", failure.Code, failure.Message)
+	}
+	if failure.BankName != "" {
+		return fmt.Sprintf("%s at %s: %s", failure.Reason, failure.BankName, failure.Message)
 	}
-	defer file.Close()
-	reader := csv.NewReader(file)
-	return reader.ReadAll()
+	return fmt.Sprintf("%s: %s", failure.Reason, failure.Message)
 }
 
-// registerAtBank manually registers a user at the bank.
-// File format: AccountId,username,password,bank_name,balance
-func registerAtBank(accountID, username, password, bankName string) error {
-	filename := fmt.Sprintf("../%s_users.txt", bankName)
-	var records [][]string
+// promptOTP asks for a one-time code if err says the gateway needs one.
+func promptOTP(reader *bufio.Reader, err error) (string, bool) {
+	if payerr.Reason(err) != pb.ErrorReason_OTP_REQUIRED {
+		return "", false
+	}
+	fmt.Print(ColorBlue + "Enter one-time code (or a recovery code): " + ColorReset)
+	code, _ := reader.ReadString('\n')
+	return strings.TrimSpace(code), true
+}
 
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		f, err := os.Create(filename)
-		if err != nil {
-			return fmt.Errorf("failed to create bank users file: %v", err)
-		}
-		defer f.Close()
-		header := "AccountId,username,password,bank_name,balance\n"
-		if _, err := f.WriteString(header); err != nil {
-			return fmt.Errorf("failed to write header: %v", err)
+// stepUp fills in the transaction PIN or one-time code that err says the
+// gateway wants, and reports whether req is worth sending again. Each is
+// asked for once.
+func stepUp(reader *bufio.Reader, err error, req *pb.TransferRequest) bool {
+	switch payerr.Reason(err) {
+	case pb.ErrorReason_PIN_REQUIRED:
+		if req.Pin != "" {
+			return false
 		}
-		records = [][]string{{"AccountId", "username", "password", "bank_name", "balance"}}
-	} else {
-		var err error
-		records, err = ReadBankUsers(bankName)
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("failed to read bank users file: %v", err)
+		fmt.Print(ColorBlue + "Enter transaction PIN: " + ColorReset)
+		pin, _ := reader.ReadString('\n')
+		req.Pin = strings.TrimSpace(pin)
+		return true
+	case pb.ErrorReason_OTP_REQUIRED:
+		if req.OtpCode != "" {
+			return false
 		}
+		req.OtpCode, _ = promptOTP(reader, err)
+		return true
 	}
+	return false
+}
 
-	for i, record := range records {
-		if i == 0 {
-			continue
-		}
-		if len(record) < 1 {
-			continue
-		}
-		if record[0] == accountID {
-			if record[1] == username && record[2] == password {
-				log.Printf(ColorCyan+"Client: Account %s already registered in bank %s."+ColorReset, accountID, bankName)
-				return nil
+// logFieldViolations prints the per-field problems the gateway attached to
+// an InvalidArgument error.
+func logFieldViolations(err error) {
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.FieldViolations {
+				log.Printf(ColorRed+"Client:   %s: %s"+ColorReset, violation.Field, violation.Description)
 			}
-			return fmt.Errorf("account number %s already registered with different credentials", accountID)
 		}
 	}
+}
+
+// promptTransfer reads the receiver and amount from the user and obtains a
+// transaction ID for the transfer.
+func promptTransfer(reader *bufio.Reader, tidClient pb.TransactionIDServiceClient, fromAccount, fromBank string) (*pb.TransferRequest, bool) {
+	fmt.Print(ColorBlue + "Enter Receiver's Account Number: " + ColorReset)
+	toAccount, _ := reader.ReadString('\n')
+	toAccount = strings.TrimSpace(toAccount)
+	fmt.Print(ColorBlue + "Enter Receiver's Bank Name: " + ColorReset)
+	toBank, _ := reader.ReadString('\n')
+	toBank = strings.TrimSpace(toBank)
+	fmt.Print(ColorBlue + "Enter Amount to Transfer: " + ColorReset)
+	amtStr, _ := reader.ReadString('\n')
+	amtStr = strings.TrimSpace(amtStr)
+	amount, err := money.ParseDecimal(amtStr, money.DefaultCurrency)
+	if err == nil {
+		err = money.ValidatePositive(amount)
+	}
+	if err != nil {
+		log.Printf(ColorRed+"Client: Invalid amount entered: %v"+ColorReset, err)
+		return nil, false
+	}
 
-	newRecord := []string{accountID, username, password, bankName, "1000.00"}
-	records = append(records, newRecord)
-	if err := WriteBankUsers(bankName, records); err != nil {
-		return fmt.Errorf("failed to update bank users file: %v", err)
+	// Request a new transaction ID from the Transaction ID Server
+	tidCtx, tidCancel := context.WithTimeout(context.Background(), 3*time.Second)
+	tidResp, err := tidClient.GetNewTransactionID(tidCtx, &pb.TransactionIDRequest{})
+	tidCancel()
+	if err != nil {
+		log.Printf(ColorRed+"Client: Failed to get transaction ID: %v"+ColorReset, err)
+		return nil, false
+	}
+	log.Printf(ColorYellow+"Client: Received Transaction ID: %s"+ColorReset, tidResp.TransactionId)
+	return &pb.TransferRequest{
+		TransactionId: tidResp.TransactionId,
+		FromAccount:   fromAccount,
+		ToAccount:     toAccount,
+		Amount:        amount,
+		FromBank:      fromBank,
+		ToBank:        toBank,
+	}, true
+}
+
+// watchTransfer prints status updates for txnID until it is final.
+func watchTransfer(client pb.PaymentGatewayClient, authCtx context.Context, txnID string) {
+	ctx, cancel := context.WithTimeout(authCtx, 2*time.Minute)
+	defer cancel()
+	stream, err := client.WatchTransfer(ctx, &pb.TransferStatusRequest{TransactionId: txnID})
+	if err != nil {
+		log.Printf(ColorRed+"Client: WatchTransfer failed: %s"+ColorReset, describeError(err))
+		return
+	}
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			log.Printf(ColorRed+"Client: Watch of txn %s ended: %s"+ColorReset, txnID, describeError(err))
+			return
+		}
+		log.Printf(ColorCyan+"Client: Txn %s: %s - %s"+ColorReset, txnID, update.State, update.Message)
 	}
-	log.Printf(ColorGreen+"Client: Successfully registered at bank %s with account %s"+ColorReset, bankName, accountID)
-	return nil
 }
 
 func main() {
@@ -156,7 +250,7 @@ func main() {
 	password := flag.String("password", "ansh", "Password")
 	accountID := flag.String("account", "ACC1", "Account number")
 	bankName := flag.String("bank", "ICICI", "Bank name")
-	registerFlag := flag.Bool("register", false, "Set to true to register user at bank")
+	registerFlag := flag.Bool("register", false, "Open a new account at the bank when registering; leave -account empty to let the bank choose its id")
 	flag.Parse()
 
 	log.Printf(ColorCyan+"[Startup] Client starting with Username: %s, Account: %s, Bank: %s"+ColorReset, *username, *accountID, *bankName)
@@ -197,7 +291,7 @@ func main() {
 	// If you set a distinct go_package for transaction_id.proto, update the import accordingly.
 	tidClient := pb.NewTransactionIDServiceClient(tidConn)
 
-	var token string
+	sess := &session{client: client}
 	var txnCounter int
 
 	// Register with the Payment Gateway
@@ -206,145 +300,121 @@ func main() {
 		defer cancel()
 		log.Printf(ColorYellow+"Client: Registering user %s at Gateway"+ColorReset, *username)
 		regResp, err := client.Register(ctx, &pb.RegisterRequest{
-			Username:  *username,
-			Password:  *password,
-			AccountId: *accountID,
-			BankName:  *bankName,
+			Username:    *username,
+			Password:    *password,
+			AccountId:   *accountID,
+			BankName:    *bankName,
+			OpenAccount: *registerFlag,
 		})
-		if err != nil {
-			log.Fatalf(ColorRed+"Client: Gateway registration failed: %v"+ColorReset, err)
-		}
-		if regResp.Success {
-			log.Printf(ColorGreen+"Client: Gateway registration successful: %s"+ColorReset, regResp.Message)
+		if payerr.Reason(err) == pb.ErrorReason_USERNAME_TAKEN {
+			// Registered on an earlier run; Authenticate checks the password.
+			log.Printf(ColorCyan+"Client: User %s is already registered at Gateway"+ColorReset, *username)
+		} else if err != nil {
+			log.Fatalf(ColorRed+"Client: Gateway registration failed: %s"+ColorReset, describeError(err))
 		} else {
-			log.Printf(ColorYellow+"Client: Gateway registration update: %s"+ColorReset, regResp.Message)
+			log.Printf(ColorGreen+"Client: Gateway registration successful: %s (account %s)"+ColorReset, regResp.Message, regResp.AccountId)
+			*accountID = regResp.AccountId
 		}
 	}
 
-	// Optionally register at the bank if the flag is set
-	if *registerFlag {
-		err := registerAtBank(*accountID, *username, *password, *bankName)
-		if err != nil {
-			log.Fatalf(ColorRed+"Client: Bank registration failed: %v"+ColorReset, err)
-		}
-	}
+	reader := bufio.NewReader(os.Stdin)
 
 	// Authenticate with the Payment Gateway
 	{
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
 		log.Printf(ColorYellow+"Client: Authenticating user %s"+ColorReset, *username)
-		authResp, err := client.Authenticate(ctx, &pb.AuthRequest{
+		var authResp *pb.AuthResponse
+		var err error
+		authReq := &pb.AuthRequest{
 			Username:  *username,
 			Password:  *password,
 			AccountId: *accountID,
-		})
+		}
+		// After earlier failed logins the gateway asks for a pause; wait it
+		// out a few times before giving up. Users with two-factor
+		// authentication are asked for a code.
+		for attempt := 1; ; attempt++ {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			authResp, err = client.Authenticate(ctx, authReq)
+			cancel()
+			if code, ok := promptOTP(reader, err); ok && authReq.OtpCode == "" {
+				authReq.OtpCode = code
+				continue
+			}
+			failure := payerr.From(err)
+			if err == nil || failure.Reason != pb.ErrorReason_LOGIN_THROTTLED || attempt == 3 {
+				break
+			}
+			log.Printf(ColorYellow+"Client: %s; waiting %s"+ColorReset, describeError(err), failure.RetryDelay)
+			time.Sleep(failure.RetryDelay)
+		}
 		if err != nil {
-			log.Fatalf(ColorRed+"Client: Authentication failed: %v"+ColorReset, err)
+			log.Fatalf(ColorRed+"Client: Authentication failed: %s"+ColorReset, describeError(err))
 		}
 		if authResp.Token == "" {
 			log.Fatalf(ColorRed+"Client: Empty token received: %s"+ColorReset, authResp.Message)
 		}
-		token = authResp.Token
-		log.Printf(ColorGreen+"Client: Authentication successful, token: %s"+ColorReset, token)
+		sess.set(authResp)
+		log.Printf(ColorGreen+"Client: Authentication successful; token valid until %s"+ColorReset, sess.expires.Format(time.RFC3339))
 	}
 
-	go processOfflineQueue(client, token)
+	go processOfflineQueue(client, sess)
 
-	reader := bufio.NewReader(os.Stdin)
 	for {
 		fmt.Println(ColorBlue + "\nSelect Operation:" + ColorReset)
 		fmt.Println("1: Transact")
 		fmt.Println("2: Check Balance")
+		fmt.Println("3: Transfer Status")
+		fmt.Println("4: Submit Transfer and Watch")
+		fmt.Println("5: Change Password")
+		fmt.Println("6: Open Another Account")
+		fmt.Println("7: Account Details")
+		fmt.Println("8: Close This Account")
+		fmt.Println("9: Set Up Two-Factor Authentication")
+		fmt.Println("10: Set Transaction PIN")
 		fmt.Println("0: Exit")
 		fmt.Print(ColorBlue + "Your choice: " + ColorReset)
 		choice, _ := reader.ReadString('\n')
 		choice = strings.TrimSpace(choice)
 		switch choice {
 		case "1":
-			fmt.Print(ColorBlue + "Enter Receiver's Account Number: " + ColorReset)
-			toAccount, _ := reader.ReadString('\n')
-			toAccount = strings.TrimSpace(toAccount)
-			fmt.Print(ColorBlue + "Enter Receiver's Bank Name: " + ColorReset)
-			toBank, _ := reader.ReadString('\n')
-			toBank = strings.TrimSpace(toBank)
-			fmt.Print(ColorBlue + "Enter Amount to Transfer: " + ColorReset)
-			amtStr, _ := reader.ReadString('\n')
-			amtStr = strings.TrimSpace(amtStr)
-			amount, err := strconv.ParseFloat(amtStr, 64)
-			if err != nil {
-				log.Printf(ColorRed + "Client: Invalid amount entered" + ColorReset)
+			transReq, ok := promptTransfer(reader, tidClient, *accountID, *bankName)
+			if !ok {
 				continue
 			}
+			txnID := transReq.TransactionId
 
-			// Request a new transaction ID from the Transaction ID Server
-			tidCtx, tidCancel := context.WithTimeout(context.Background(), 3*time.Second)
-			tidResp, err := tidClient.GetNewTransactionID(tidCtx, &pb.TransactionIDRequest{})
-			tidCancel()
-			if err != nil {
-				log.Printf(ColorRed+"Client: Failed to get transaction ID: %v"+ColorReset, err)
-				continue
-			}
-			txnID := tidResp.TransactionId
-			log.Printf(ColorYellow+"Client: Received Transaction ID: %s"+ColorReset, txnID)
-
-			md := metadata.New(map[string]string{"authorization": token})
-			authCtx := metadata.NewOutgoingContext(context.Background(), md)
+			authCtx := sess.authContext()
 			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
-			transReq := &pb.TransferRequest{
-				TransactionId: txnID,
-				FromAccount:   *accountID,
-				ToAccount:     toAccount,
-				Amount:        amount,
-				FromBank:      *bankName,
-				ToBank:        toBank,
-			}
 			resp, err := client.TransferMoney(ctx, transReq)
 			cancel()
-			if err != nil {
-				log.Printf(ColorRed+"Client: TransferMoney RPC failed: %v"+ColorReset, err)
+			for stepUp(reader, err, transReq) {
+				ctx, cancel = context.WithTimeout(authCtx, 5*time.Second)
+				resp, err = client.TransferMoney(ctx, transReq)
+				cancel()
+			}
+			if err != nil && !shouldRetry(err) {
+				log.Printf(ColorRed+"Client: Permanent failure: %s"+ColorReset, describeError(err))
+				logFieldViolations(err)
+			} else if err != nil {
+				log.Printf(ColorRed+"Client: Transfer failed: %s"+ColorReset, describeError(err))
 				addToOfflineQueue(transReq)
 			} else {
-				if resp.Success {
-					log.Printf(ColorGreen+"Client: Transfer successful: %s"+ColorReset, resp.Message)
-					txnCounter++
-					if txnCounter%3 == 0 {
-						log.Printf(ColorBlue+"Client: Testing idempotency for txn %s"+ColorReset, txnID)
-						ctxDup, cancelDup := context.WithTimeout(authCtx, 5*time.Second)
-						dupResp, err := client.TransferMoney(ctxDup, transReq)
-						cancelDup()
-						if err != nil {
-							log.Printf(ColorRed+"Client: Duplicate txn failed: %v"+ColorReset, err)
-						} else {
-							log.Printf(ColorGreen+"Client: Duplicate txn response: %s"+ColorReset, dupResp.Message)
-						}
-					}
-				} else {
-					lowerMsg := strings.ToLower(resp.Message)
-					permanentFailures := []string{
-						"not registered", "does not exist", "self-transfer not allowed",
-						"insufficient", "invalid credentials", "already processed",
-						"duplicate transaction", "already registered", "invalid transaction", "malformed",
-						"account not found",
-					}
-					isPermanent := false
-					for _, keyword := range permanentFailures {
-						if strings.Contains(lowerMsg, keyword) {
-							isPermanent = true
-							break
-						}
-					}
-					if isPermanent {
-						log.Printf(ColorRed+"Client: Permanent failure: %s"+ColorReset, resp.Message)
+				log.Printf(ColorGreen+"Client: Transfer successful: %s"+ColorReset, resp.Message)
+				txnCounter++
+				if txnCounter%3 == 0 {
+					log.Printf(ColorBlue+"Client: Testing idempotency for txn %s"+ColorReset, txnID)
+					ctxDup, cancelDup := context.WithTimeout(authCtx, 5*time.Second)
+					dupResp, err := client.TransferMoney(ctxDup, transReq)
+					cancelDup()
+					if err != nil {
+						log.Printf(ColorRed+"Client: Duplicate txn failed: %s"+ColorReset, describeError(err))
 					} else {
-						log.Printf(ColorRed+"Client: Transfer failed: %s"+ColorReset, resp.Message)
-						addToOfflineQueue(transReq)
+						log.Printf(ColorGreen+"Client: Duplicate txn response: %s"+ColorReset, dupResp.Message)
 					}
 				}
 			}
 		case "2":
-			md := metadata.New(map[string]string{"authorization": token})
-			authCtx := metadata.NewOutgoingContext(context.Background(), md)
+			authCtx := sess.authContext()
 			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
 			balResp, err := client.CheckBalance(ctx, &pb.BalanceRequest{
 				AccountId: *accountID,
@@ -352,12 +422,164 @@ func main() {
 			})
 			cancel()
 			if err != nil {
-				log.Printf(ColorRed+"Client: CheckBalance failed: %v"+ColorReset, err)
+				log.Printf(ColorRed+"Client: CheckBalance failed: %s"+ColorReset, describeError(err))
 			} else {
-				log.Printf(ColorGreen+"Client: Current balance: %.2f"+ColorReset, balResp.Balance)
+				log.Printf(ColorGreen+"Client: Current balance: %s (available: %s) - %s"+ColorReset,
+					money.Format(balResp.Balance), money.Format(balResp.AvailableBalance), balResp.Message)
+			}
+		case "3":
+			fmt.Print(ColorBlue + "Enter Transaction ID: " + ColorReset)
+			txnID, _ := reader.ReadString('\n')
+			txnID = strings.TrimSpace(txnID)
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			statusResp, err := client.GetTransferStatus(ctx, &pb.TransferStatusRequest{TransactionId: txnID})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: GetTransferStatus failed: %s"+ColorReset, describeError(err))
+				continue
+			}
+			log.Printf(ColorGreen+"Client: Txn %s: %s (decision %s, amount %s) - %s"+ColorReset,
+				statusResp.TransactionId, statusResp.State, statusResp.Decision, money.Format(statusResp.Amount), statusResp.Message)
+			for _, leg := range statusResp.Legs {
+				log.Printf(ColorCyan+"Client:   %s %s/%s: %s %s (updated %s)"+ColorReset,
+					leg.Leg, leg.BankName, leg.AccountId, leg.State, leg.Message,
+					time.UnixMilli(leg.UpdatedUnixMs).Format(time.RFC3339))
+			}
+		case "4":
+			transReq, ok := promptTransfer(reader, tidClient, *accountID, *bankName)
+			if !ok {
+				continue
+			}
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			subResp, err := client.SubmitTransfer(ctx, transReq)
+			cancel()
+			for stepUp(reader, err, transReq) {
+				ctx, cancel = context.WithTimeout(authCtx, 5*time.Second)
+				subResp, err = client.SubmitTransfer(ctx, transReq)
+				cancel()
+			}
+			if err != nil {
+				log.Printf(ColorRed+"Client: SubmitTransfer failed: %s"+ColorReset, describeError(err))
+				logFieldViolations(err)
+				continue
+			}
+			log.Printf(ColorGreen+"Client: Txn %s submitted: %s"+ColorReset, subResp.TransactionId, subResp.Message)
+			watchTransfer(client, authCtx, subResp.TransactionId)
+		case "5":
+			fmt.Print(ColorBlue + "Enter current password: " + ColorReset)
+			oldPassword, _ := reader.ReadString('\n')
+			fmt.Print(ColorBlue + "Enter new password: " + ColorReset)
+			newPassword, _ := reader.ReadString('\n')
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			authResp, err := client.ChangePassword(ctx, &pb.ChangePasswordRequest{
+				OldPassword: strings.TrimSpace(oldPassword),
+				NewPassword: strings.TrimSpace(newPassword),
+			})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: ChangePassword failed: %s"+ColorReset, describeError(err))
+				logFieldViolations(err)
+				continue
+			}
+			sess.set(authResp)
+			log.Printf(ColorGreen+"Client: %s; token valid until %s"+ColorReset, authResp.Message, sess.expires.Format(time.RFC3339))
+		case "6":
+			fmt.Print(ColorBlue + "Enter Bank Name: " + ColorReset)
+			openBank, _ := reader.ReadString('\n')
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			acct, err := client.OpenAccount(ctx, &pb.OpenAccountRequest{BankName: strings.TrimSpace(openBank)})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: OpenAccount failed: %s"+ColorReset, describeError(err))
+				logFieldViolations(err)
+				continue
+			}
+			log.Printf(ColorGreen+"Client: Opened account %s at %s (%s); restart with -account=%s -bank=%s to use it"+ColorReset,
+				acct.AccountId, acct.BankName, acct.CurrencyCode, acct.AccountId, acct.BankName)
+		case "7":
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			acct, err := client.GetAccount(ctx, &pb.AccountRequest{AccountId: *accountID, BankName: *bankName})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: GetAccount failed: %s"+ColorReset, describeError(err))
+				continue
+			}
+			log.Printf(ColorGreen+"Client: Account %s at %s held by %s in %s"+ColorReset, acct.AccountId, acct.BankName, acct.Username, acct.CurrencyCode)
+		case "8":
+			fmt.Print(ColorYellow + "Close account " + *accountID + " at " + *bankName + "? Type 'yes' to confirm: " + ColorReset)
+			confirm, _ := reader.ReadString('\n')
+			if strings.TrimSpace(confirm) != "yes" {
+				continue
+			}
+			authCtx := sess.authContext()
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			closeResp, err := client.CloseAccount(ctx, &pb.AccountRequest{AccountId: *accountID, BankName: *bankName})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: CloseAccount failed: %s"+ColorReset, describeError(err))
+				continue
+			}
+			log.Printf(ColorGreen+"Client: %s; exiting"+ColorReset, closeResp.Message)
+			sess.logout()
+			return
+		case "9":
+			authCtx := sess.authContext()
+			enrollReq := &pb.EnrollTotpRequest{}
+			ctx, cancel := context.WithTimeout(authCtx, 5*time.Second)
+			enrollResp, err := client.EnrollTotp(ctx, enrollReq)
+			cancel()
+			if code, ok := promptOTP(reader, err); ok {
+				// Replacing an active secret needs a code for it.
+				enrollReq.OtpCode = code
+				ctx, cancel = context.WithTimeout(authCtx, 5*time.Second)
+				enrollResp, err = client.EnrollTotp(ctx, enrollReq)
+				cancel()
+			}
+			if err != nil {
+				log.Printf(ColorRed+"Client: EnrollTotp failed: %s"+ColorReset, describeError(err))
+				continue
+			}
+			fmt.Println(ColorCyan + "Secret: " + enrollResp.Secret + ColorReset)
+			fmt.Println(ColorCyan + "URI:    " + enrollResp.OtpauthUri + ColorReset)
+			fmt.Println(ColorYellow + "Recovery codes (each works once; store them safely, they are not shown again):" + ColorReset)
+			for _, code := range enrollResp.RecoveryCodes {
+				fmt.Println("  " + code)
+			}
+			fmt.Print(ColorBlue + "Enter the code your authenticator app shows: " + ColorReset)
+			code, _ := reader.ReadString('\n')
+			ctx, cancel = context.WithTimeout(authCtx, 5*time.Second)
+			confirmResp, err := client.ConfirmTotp(ctx, &pb.ConfirmTotpRequest{OtpCode: strings.TrimSpace(code)})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: ConfirmTotp failed: %s"+ColorReset, describeError(err))
+				continue
+			}
+			log.Printf(ColorGreen+"Client: %s"+ColorReset, confirmResp.Message)
+		case "10":
+			fmt.Print(ColorBlue + "Enter password: " + ColorReset)
+			pw, _ := reader.ReadString('\n')
+			fmt.Print(ColorBlue + "Enter new PIN (empty to remove): " + ColorReset)
+			pin, _ := reader.ReadString('\n')
+			ctx, cancel := context.WithTimeout(sess.authContext(), 5*time.Second)
+			pinResp, err := client.SetTransactionPin(ctx, &pb.SetTransactionPinRequest{
+				Password: strings.TrimSpace(pw),
+				NewPin:   strings.TrimSpace(pin),
+			})
+			cancel()
+			if err != nil {
+				log.Printf(ColorRed+"Client: SetTransactionPin failed: %s"+ColorReset, describeError(err))
+				logFieldViolations(err)
+				continue
 			}
+			log.Printf(ColorGreen+"Client: %s"+ColorReset, pinResp.Message)
 		case "0":
 			log.Printf(ColorBlue + "Client: Exiting..." + ColorReset)
+			sess.logout()
 			return
 		default:
 			log.Printf(ColorRed + "Client: Invalid choice, try again." + ColorReset)
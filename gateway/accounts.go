@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+
+	"google.golang.org/grpc"
+)
+
+// connectBank dials a registered bank. Failures are returned as payerr
+// errors ready to send to the caller.
+func connectBank(bankName string) (*grpc.ClientConn, pb.BankClient, error) {
+	bankAddr, ok := getBankAddress(bankName)
+	if !ok {
+		msg := fmt.Sprintf("Bank '%s' is not registered.", bankName)
+		log.Printf("Gateway: %s", msg)
+		return nil, nil, payerr.New(pb.ErrorReason_BANK_NOT_REGISTERED, msg).Err()
+	}
+	conn, client, err := dialBank(bankAddr)
+	if err != nil {
+		log.Printf("Gateway: Failed to connect to bank at %s: %v", bankAddr, err)
+		failure := payerr.New(pb.ErrorReason_BANK_UNAVAILABLE, "Failed to connect to bank.")
+		failure.BankName = bankName
+		return nil, nil, failure.Err()
+	}
+	return conn, client, nil
+}
+
+// openBankAccount asks the bank to open an account held by username. When
+// account names one the bank already holds for username and no gateway user
+// has linked, that account is returned instead, provided the bank accepts
+// bankPassword for it: an earlier attempt opened it but failed before
+// linking it, and the retry picks it up.
+func openBankAccount(ctx context.Context, username, bankPassword, bankName, account string) (*pb.AccountResponse, error) {
+	conn, client, err := connectBank(bankName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	acct, err := client.OpenAccount(ctx, &pb.OpenAccountRequest{BankName: bankName, AccountId: account, Username: username})
+	if err == nil {
+		return acct, nil
+	}
+	failure := bankFailure(bankName, err)
+	if failure.Reason == pb.ErrorReason_ACCOUNT_EXISTS && account != "" && ownerOf(bankName, account) == "" {
+		existing, verifyErr := verifyBankAccount(ctx, username, bankPassword, bankName, account)
+		if verifyErr == nil {
+			log.Printf("Gateway: Account %s at %s already opened for %s; linking it", account, bankName, username)
+			return existing, nil
+		}
+		if bankPassword != "" && payerr.Reason(verifyErr) == pb.ErrorReason_INVALID_CREDENTIALS {
+			// A guessed password is reported as such, so it counts as one.
+			return nil, verifyErr
+		}
+	}
+	log.Printf("Gateway: Bank OpenAccount error: %s (%s)", failure.Message, failure.Reason)
+	return nil, failure.Err()
+}
+
+// lookupBankAccount asks the bank for account and checks that username
+// holds it.
+func lookupBankAccount(ctx context.Context, username, bankName, account string) (*pb.AccountResponse, error) {
+	conn, client, err := connectBank(bankName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	acct, err := client.GetAccount(ctx, &pb.AccountRequest{AccountId: account, BankName: bankName})
+	if err != nil {
+		failure := bankFailure(bankName, err)
+		log.Printf("Gateway: Bank GetAccount error: %s (%s)", failure.Message, failure.Reason)
+		return nil, failure.Err()
+	}
+	if acct.Username != username {
+		msg := fmt.Sprintf("Account %s at %s is not held by %s", account, bankName, username)
+		log.Printf("Gateway: %s", msg)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED, msg).Err()
+	}
+	return acct, nil
+}
+
+// verifyBankAccount asks the bank to confirm that username holds account
+// and that bankPassword is its bank password. Accounts the bank holds are
+// only linked to a gateway user once it does.
+func verifyBankAccount(ctx context.Context, username, bankPassword, bankName, account string) (*pb.AccountResponse, error) {
+	conn, client, err := connectBank(bankName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	acct, err := client.VerifyAccountHolder(ctx, &pb.VerifyAccountHolderRequest{AccountId: account, BankName: bankName, Username: username, Password: bankPassword})
+	if err != nil {
+		failure := bankFailure(bankName, err)
+		log.Printf("Gateway: Bank VerifyAccountHolder error for account %s: %s (%s)", account, failure.Message, failure.Reason)
+		return nil, failure.Err()
+	}
+	return acct, nil
+}
+
+// closeBankAccount asks the bank to close account.
+func closeBankAccount(ctx context.Context, bankName, account string) (*pb.CloseAccountResponse, error) {
+	conn, client, err := connectBank(bankName)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	resp, err := client.CloseAccount(ctx, &pb.AccountRequest{AccountId: account, BankName: bankName})
+	if err != nil {
+		failure := bankFailure(bankName, err)
+		log.Printf("Gateway: Bank CloseAccount error: %s (%s)", failure.Message, failure.Reason)
+		return nil, failure.Err()
+	}
+	return resp, nil
+}
+
+// linkAccountTo links an account the bank holds for username to them.
+func linkAccountTo(username string, acct *pb.AccountResponse) error {
+	linked, err := LinkAccount(username, acct.BankName, acct.AccountId)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to update gateway accounts file: %v", err)
+		log.Printf("Gateway: Linking account %s at %s to %s failed: %s", acct.AccountId, acct.BankName, username, msg)
+		return payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
+	}
+	if !linked {
+		msg := fmt.Sprintf("Account %s at %s is registered to another user", acct.AccountId, acct.BankName)
+		log.Printf("Gateway: Linking account to %s rejected: %s", username, msg)
+		return payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED, msg).Err()
+	}
+	return nil
+}
+
+// OpenAccount opens an account for the caller and links it to them.
+func (s *server) OpenAccount(ctx context.Context, req *pb.OpenAccountRequest) (*pb.AccountResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: OpenAccount called by %s at bank %s", caller.username, req.BankName)
+	if req.Username != "" && req.Username != caller.username {
+		msg := fmt.Sprintf("Accounts can only be opened for the caller, not %s", req.Username)
+		return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED, msg).Err()
+	}
+	// The caller has no bank password to offer, so only an account opened
+	// through the gateway, which has none, is picked up.
+	acct, err := openBankAccount(ctx, caller.username, "", req.BankName, req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	if err := linkAccountTo(caller.username, acct); err != nil {
+		// The account stays open at the bank; retrying with its id links it.
+		return nil, err
+	}
+	log.Printf("Gateway: Account %s at %s opened for %s", acct.AccountId, acct.BankName, caller.username)
+	return acct, nil
+}
+
+// CloseAccount closes one of the caller's accounts at its bank and unlinks
+// it.
+func (s *server) CloseAccount(ctx context.Context, req *pb.AccountRequest) (*pb.CloseAccountResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: CloseAccount called by %s for account %s at %s", caller.username, req.AccountId, req.BankName)
+	resp, err := closeBankAccount(ctx, req.BankName, req.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	if err := UnlinkAccount(req.BankName, req.AccountId); err != nil {
+		// The account is gone at the bank. The stale link only stops its id
+		// from being linked to anyone else.
+		log.Printf("Gateway: Failed to unlink closed account %s at %s: %v", req.AccountId, req.BankName, err)
+	}
+	log.Printf("Gateway: Account %s at %s of %s closed", req.AccountId, req.BankName, caller.username)
+	return resp, nil
+}
+
+// GetAccount returns the bank's record of one of the caller's accounts.
+func (s *server) GetAccount(ctx context.Context, req *pb.AccountRequest) (*pb.AccountResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: GetAccount called for account: %s, bank: %s", req.AccountId, req.BankName)
+	return lookupBankAccount(ctx, caller.username, req.BankName, req.AccountId)
+}
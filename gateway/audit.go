@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/csv"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const auditFile = "../gateway_audit.txt"
+
+// auditLog serialises appends to the audit file.
+var auditLog sync.Mutex
+
+// audit records a security event in gateway_audit.txt and the gateway log.
+// File format: time,event,username,peer,detail
+func audit(event, username, peer, detail string) {
+	log.Printf("Gateway: AUDIT %s user=%q peer=%q: %s", event, username, peer, detail)
+	auditLog.Lock()
+	defer auditLog.Unlock()
+	_, statErr := os.Stat(auditFile)
+	f, err := os.OpenFile(auditFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Printf("Gateway: Could not open audit file: %v", err)
+		return
+	}
+	defer f.Close()
+	writer := csv.NewWriter(f)
+	if os.IsNotExist(statErr) {
+		writer.Write([]string{"time", "event", "username", "peer", "detail"})
+	}
+	writer.Write([]string{time.Now().UTC().Format(time.RFC3339), event, username, peer, detail})
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Gateway: Could not write audit event: %v", err)
+	}
+}
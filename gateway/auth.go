@@ -1,129 +1,400 @@
 package main
 
 import (
-	"bufio"
+	"bytes"
 	"encoding/csv"
-	"io/ioutil"
+	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"payment_gateway/password"
+	"payment_gateway/safefile"
 )
 
-// User holds in-memory registration info (only username and password).
+// User holds in-memory registration info. Only a salted hash of the
+// password is kept. PasswordVersion counts password changes; tokens carry
+// the version they were issued under and stop being accepted once it moves.
 type User struct {
-	Password string
+	PasswordHash    string
+	PasswordVersion int
+	// TOTPSecret is set once the user has enrolled in two-factor
+	// authentication. RecoveryCodes holds hashes of the unused recovery codes.
+	TOTPSecret    string
+	RecoveryCodes []string
+	// TransactionPIN is a hash of the PIN transfers must carry, if set.
+	TransactionPIN string
+}
+
+// usersFileHeader names the columns of gateway_users.txt. Recovery code
+// hashes are joined with ';'.
+var usersFileHeader = []string{"username", "password", "password_version", "totp_secret", "recovery_codes", "transaction_pin"}
+
+// record returns the gateway_users.txt row of user.
+func (u User) record(username string) []string {
+	return []string{username, u.PasswordHash, strconv.Itoa(u.PasswordVersion), u.TOTPSecret, strings.Join(u.RecoveryCodes, ";"), u.TransactionPIN}
+}
+
+// accountRef names one bank account.
+type accountRef struct {
+	bank    string
+	account string
+}
+
+// tokenInfo is what a token was issued for: a user and the accounts the
+// holder may act on.
+type tokenInfo struct {
+	username string
+	accounts []accountRef
+	id       string
+	expires  time.Time
+	version  int // password version of the user when the token was issued
+}
+
+// owns reports whether the token holder may act on account at bank.
+func (t tokenInfo) owns(bank, account string) bool {
+	for _, ref := range t.accounts {
+		if ref.bank == bank && ref.account == account {
+			return true
+		}
+	}
+	return false
 }
 
-var tokenStore = struct {
+// accountStore records which user each account belongs to. Accounts are
+// linked when their owner registers with the gateway.
+var accountStore = struct {
 	sync.RWMutex
-	tokens map[string]string // token -> username mapping
-}{tokens: make(map[string]string)}
+	owners map[accountRef]string // account -> username
+}{owners: make(map[accountRef]string)}
 
 var userStore = struct {
 	sync.RWMutex
 	users map[string]User // username -> User
 }{users: make(map[string]User)}
 
-// AddToken stores a token for a username.
-func AddToken(token string, username string) {
-	tokenStore.Lock()
-	defer tokenStore.Unlock()
-	tokenStore.tokens[token] = username
+// AccountsOf returns the accounts linked to username.
+func AccountsOf(username string) []accountRef {
+	accountStore.RLock()
+	defer accountStore.RUnlock()
+	var accounts []accountRef
+	for ref, owner := range accountStore.owners {
+		if owner == username {
+			accounts = append(accounts, ref)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].bank != accounts[j].bank {
+			return accounts[i].bank < accounts[j].bank
+		}
+		return accounts[i].account < accounts[j].account
+	})
+	return accounts
+}
+
+// LinkAccount records username as the owner of account at bank and adds
+// the link to gateway_accounts.txt. An account linked to another user is
+// left alone and reported with linked false.
+func LinkAccount(username, bank, account string) (linked bool, err error) {
+	ref := accountRef{bank: bank, account: account}
+	accountStore.Lock()
+	defer accountStore.Unlock()
+	if owner, ok := accountStore.owners[ref]; ok {
+		return owner == username, nil
+	}
+	accountStore.owners[ref] = username
+	if err := writeGatewayAccountsLocked(); err != nil {
+		delete(accountStore.owners, ref)
+		return false, err
+	}
+	return true, nil
+}
+
+// UnlinkAccount forgets the owner of a closed account and rewrites
+// gateway_accounts.txt without it.
+func UnlinkAccount(bank, account string) error {
+	ref := accountRef{bank: bank, account: account}
+	accountStore.Lock()
+	defer accountStore.Unlock()
+	owner, ok := accountStore.owners[ref]
+	if !ok {
+		return nil
+	}
+	delete(accountStore.owners, ref)
+	if err := writeGatewayAccountsLocked(); err != nil {
+		accountStore.owners[ref] = owner
+		return err
+	}
+	return nil
+}
+
+// writeGatewayAccountsLocked rewrites gateway_accounts.txt from
+// accountStore, which the caller must hold.
+func writeGatewayAccountsLocked() error {
+	records := [][]string{{"username", "account_id", "bank_name"}}
+	for ref, owner := range accountStore.owners {
+		records = append(records, []string{owner, ref.account, ref.bank})
+	}
+	sort.Slice(records[1:], func(i, j int) bool {
+		a, b := records[1+i], records[1+j]
+		if a[2] != b[2] {
+			return a[2] < b[2]
+		}
+		return a[1] < b[1]
+	})
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	return safefile.WriteFile("../gateway_accounts.txt", buf.Bytes(), 0644)
 }
 
-// ValidateToken checks if a token exists.
-func ValidateToken(token string) bool {
-	tokenStore.RLock()
-	defer tokenStore.RUnlock()
-	_, exists := tokenStore.tokens[token]
-	return exists
+// ownerOf returns the user account at bank is linked to, if any.
+func ownerOf(bank, account string) string {
+	accountStore.RLock()
+	defer accountStore.RUnlock()
+	return accountStore.owners[accountRef{bank: bank, account: account}]
 }
 
-// RegisterUser adds or updates a user.
-func RegisterUser(username, password string) {
+// LoadGatewayAccounts loads account links from gateway_accounts.txt.
+// File format: username,account_id,bank_name
+func LoadGatewayAccounts() {
+	data, err := safefile.ReadFile("../gateway_accounts.txt")
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Gateway: Could not open gateway accounts file: %v", err)
+		return
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		log.Printf("Gateway: Could not read gateway accounts file: %v", err)
+		return
+	}
+	accountStore.Lock()
+	defer accountStore.Unlock()
+	for i, record := range records {
+		if i == 0 || len(record) < 3 {
+			continue
+		}
+		accountStore.owners[accountRef{bank: record[2], account: record[1]}] = record[0]
+	}
+	log.Printf("Gateway: Loaded %d account links from gateway_accounts.txt", len(accountStore.owners))
+}
+
+// AddUser adds a new user, given the hash of their password. It reports
+// false and changes nothing if the username is already taken.
+func AddUser(username, passwordHash string) bool {
 	userStore.Lock()
 	defer userStore.Unlock()
-	userStore.users[username] = User{Password: password}
+	if _, ok := userStore.users[username]; ok {
+		return false
+	}
+	userStore.users[username] = User{PasswordHash: passwordHash}
+	return true
 }
 
-// ValidateUser checks if a username exists and password matches.
-func ValidateUser(username, password string) bool {
+// RemoveUser drops a user added by a registration that could not be
+// completed.
+func RemoveUser(username string) {
+	userStore.Lock()
+	defer userStore.Unlock()
+	delete(userStore.users, username)
+}
+
+// UserExists reports whether username is registered.
+func UserExists(username string) bool {
 	userStore.RLock()
 	defer userStore.RUnlock()
-	user, exists := userStore.users[username]
-	if !exists {
-		return false
+	_, ok := userStore.users[username]
+	return ok
+}
+
+// GetUser returns the registration info of username.
+func GetUser(username string) (User, bool) {
+	userStore.RLock()
+	defer userStore.RUnlock()
+	user, ok := userStore.users[username]
+	return user, ok
+}
+
+// UpdateUser applies change to an existing user and saves the result to
+// gateway_users.txt. The in-memory user is only updated once the file is
+// written; if change fails nothing is.
+func UpdateUser(username string, change func(*User) error) (User, error) {
+	gatewayUsersFile.Lock()
+	defer gatewayUsersFile.Unlock()
+	user, ok := GetUser(username)
+	if !ok {
+		return User{}, fmt.Errorf("user %s is not registered", username)
 	}
-	return user.Password == password
+	user.RecoveryCodes = append([]string(nil), user.RecoveryCodes...)
+	if err := change(&user); err != nil {
+		return User{}, err
+	}
+	if err := writeGatewayUser(username, user); err != nil {
+		return User{}, err
+	}
+	userStore.Lock()
+	userStore.users[username] = user
+	userStore.Unlock()
+	return user, nil
 }
 
-// WriteGatewayUsers writes records to gateway_users.txt.
+// ChangeUserPassword replaces the password hash of an existing user, bumps
+// their password version and saves both to gateway_users.txt.
+func ChangeUserPassword(username, passwordHash string) (User, error) {
+	return UpdateUser(username, func(user *User) error {
+		user.PasswordHash = passwordHash
+		user.PasswordVersion++
+		return nil
+	})
+}
+
+// PasswordVersion returns the current password version of username.
+func PasswordVersion(username string) (int, bool) {
+	userStore.RLock()
+	defer userStore.RUnlock()
+	user, ok := userStore.users[username]
+	return user.PasswordVersion, ok
+}
+
+// ValidateUser checks if a username exists and password matches. It also
+// returns the password version the check was made against.
+func ValidateUser(username, pw string) (version int, ok bool) {
+	userStore.RLock()
+	user := userStore.users[username]
+	userStore.RUnlock()
+	return user.PasswordVersion, password.Check(user.PasswordHash, pw)
+}
+
+// WriteGatewayUsers writes records to gateway_users.txt. The file holds
+// every login, so it is replaced whole through safefile rather than
+// rewritten in place.
 func WriteGatewayUsers(filename string, records [][]string) error {
-	file, err := os.Create(filename)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
 		return err
 	}
-	defer file.Close()
-	writer := csv.NewWriter(file)
-	err = writer.WriteAll(records)
-	if err != nil {
-		return err
-	}
-	writer.Flush()
-	return nil
+	return safefile.WriteFile(filename, buf.Bytes(), 0644)
 }
 
+// gatewayUsersFile serialises rewrites of gateway_users.txt.
+var gatewayUsersFile sync.Mutex
+
 // AppendOrUpdateGatewayUser updates or appends user info in gateway_users.txt.
-// File format: username,password
-func AppendOrUpdateGatewayUser(username, password string) error {
+// File format: see usersFileHeader; the password is a bcrypt hash.
+func AppendOrUpdateGatewayUser(username string, user User) error {
+	gatewayUsersFile.Lock()
+	defer gatewayUsersFile.Unlock()
+	return writeGatewayUser(username, user)
+}
+
+func writeGatewayUser(username string, user User) error {
 	filename := "../gateway_users.txt"
 	var records [][]string
-	if f, err := os.Open(filename); err == nil {
-		defer f.Close()
-		reader := csv.NewReader(f)
+	data, err := safefile.ReadFile(filename)
+	if err == nil {
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
 		records, err = reader.ReadAll()
 		if err != nil {
 			return err
 		}
+	} else if !os.IsNotExist(err) {
+		return err
 	}
 	if records == nil || len(records) == 0 {
-		records = [][]string{{"username", "password"}}
+		records = [][]string{usersFileHeader}
 	}
+	records[0] = usersFileHeader
+	row := user.record(username)
 	updated := false
 	for i, record := range records {
-		if record[0] == username {
-			records[i][1] = password
+		if i > 0 && len(record) < len(usersFileHeader) {
+			// Rows from before the later columns existed.
+			if len(record) == 2 {
+				record = append(record, "0")
+			}
+			for len(record) < len(usersFileHeader) {
+				record = append(record, "")
+			}
+			records[i] = record
+		}
+		if i > 0 && record[0] == username {
+			records[i] = row
 			updated = true
 			break
 		}
 	}
 	if !updated {
-		records = append(records, []string{username, password})
+		records = append(records, row)
 	}
 	return WriteGatewayUsers(filename, records)
 }
 
-// LoadGatewayUsers loads registered users from gateway_users.txt.
+// LoadGatewayUsers loads registered users from gateway_users.txt. Rows
+// still holding a plaintext password are hashed and the file rewritten.
 func LoadGatewayUsers() {
 	filename := "../gateway_users.txt"
-	data, err := ioutil.ReadFile(filename)
+	data, err := safefile.ReadFile(filename)
 	if err != nil {
 		log.Printf("Gateway: Could not open gateway users file: %v", err)
 		return
 	}
-	reader := csv.NewReader(bufio.NewReader(strings.NewReader(string(data))))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1 // older rows lack the later columns
 	records, err := reader.ReadAll()
 	if err != nil {
 		log.Printf("Gateway: Could not read gateway users file: %v", err)
 		return
 	}
 	log.Printf("Gateway: Loaded %d user records from gateway_users.txt", len(records))
-	for _, record := range records {
-		if len(record) < 2 {
+	hashed := 0
+	for i, record := range records {
+		if i == 0 || len(record) < 2 {
 			continue
 		}
-		RegisterUser(record[0], record[1])
+		if !password.IsHash(record[1]) {
+			hash, err := password.Hash(record[1])
+			if err != nil {
+				log.Printf("Gateway: Could not hash password of user %s; user not loaded: %v", record[0], err)
+				continue
+			}
+			records[i][1] = hash
+			hashed++
+		}
+		user := User{PasswordHash: records[i][1]}
+		if len(record) > 2 {
+			if user.PasswordVersion, err = strconv.Atoi(record[2]); err != nil {
+				log.Printf("Gateway: Bad password version for user %s; user not loaded: %v", record[0], err)
+				continue
+			}
+		}
+		if len(record) > 4 {
+			user.TOTPSecret = record[3]
+			if record[4] != "" {
+				user.RecoveryCodes = strings.Split(record[4], ";")
+			}
+		}
+		if len(record) > 5 {
+			user.TransactionPIN = record[5]
+		}
+		userStore.Lock()
+		userStore.users[record[0]] = user
+		userStore.Unlock()
+	}
+	if hashed > 0 {
+		if err := WriteGatewayUsers(filename, records); err != nil {
+			log.Printf("Gateway: Could not rewrite gateway users file with hashed passwords: %v", err)
+		} else {
+			log.Printf("Gateway: Replaced %d plaintext password(s) in gateway_users.txt with hashes", hashed)
+		}
 	}
 	log.Printf("Gateway: Finished loading users from gateway_users.txt")
 }
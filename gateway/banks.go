@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
+)
+
+// bankCertOU marks certificates issued to banks. A bank registers with a
+// certificate carrying it and the bank name as common name; the shared
+// client certificate has neither.
+const bankCertOU = "bank"
+
+const bankKeysFile = "../gateway_bank_keys.txt"
+
+// bankKeys pins each bank name to the public key it first registered with,
+// so that another certificate issued for the same name cannot take over
+// the bank's routing. A bank that rotates its key is let in again with the
+// 'forget-bank' command.
+var bankKeys = struct {
+	sync.Mutex
+	pinned map[string]string // bank name -> SHA-256 of the public key, hex
+}{pinned: make(map[string]string)}
+
+// LoadBankKeys loads pinned bank keys from gateway_bank_keys.txt.
+// File format: bank_name,key_sha256
+func LoadBankKeys() {
+	data, err := safefile.ReadFile(bankKeysFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("Gateway: Could not open bank keys file: %v", err)
+		return
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		log.Printf("Gateway: Could not read bank keys file: %v", err)
+		return
+	}
+	bankKeys.Lock()
+	defer bankKeys.Unlock()
+	for i, record := range records {
+		if i == 0 || len(record) < 2 {
+			continue
+		}
+		bankKeys.pinned[record[0]] = record[1]
+	}
+	log.Printf("Gateway: Loaded %d pinned bank keys from gateway_bank_keys.txt", len(bankKeys.pinned))
+}
+
+// writeBankKeysLocked rewrites gateway_bank_keys.txt from bankKeys, which
+// the caller must hold.
+func writeBankKeysLocked(pinned map[string]string) error {
+	records := [][]string{{"bank_name", "key_sha256"}}
+	for bank, key := range pinned {
+		records = append(records, []string{bank, key})
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	return safefile.WriteFile(bankKeysFile, buf.Bytes(), 0600)
+}
+
+// bankIdentity returns the bank name and key fingerprint of the verified
+// client certificate the caller connected with.
+func bankIdentity(ctx context.Context) (name, key string, err error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", "", fmt.Errorf("no peer information")
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", "", fmt.Errorf("no verified client certificate")
+	}
+	cert := tlsInfo.State.VerifiedChains[0][0]
+	isBank := false
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		isBank = isBank || ou == bankCertOU
+	}
+	if !isBank {
+		return "", "", fmt.Errorf("certificate %q is not a bank certificate", cert.Subject.CommonName)
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return cert.Subject.CommonName, hex.EncodeToString(sum[:]), nil
+}
+
+// BankRegister records the address of a bank. The caller must present the
+// bank's own certificate, and after the first registration the same key.
+// Every change to the registry is audited.
+func (s *server) BankRegister(ctx context.Context, req *pb.BankRegisterRequest) (*pb.BankRegisterResponse, error) {
+	peerAddr := peerHost(ctx)
+	reject := func(format string, args ...interface{}) error {
+		msg := fmt.Sprintf(format, args...)
+		audit("bank_register_rejected", "", peerAddr, fmt.Sprintf("bank %s at %s: %s", req.BankName, req.BankAddress, msg))
+		return payerr.New(pb.ErrorReason_BANK_IDENTITY_MISMATCH, "Bank registration rejected: "+msg).Err()
+	}
+	name, key, err := bankIdentity(ctx)
+	if err != nil {
+		return nil, reject("%v", err)
+	}
+	if name != req.BankName {
+		return nil, reject("certificate is for bank %q", name)
+	}
+	bankKeys.Lock()
+	defer bankKeys.Unlock()
+	if pinned, ok := bankKeys.pinned[name]; !ok {
+		bankKeys.pinned[name] = key
+		if err := writeBankKeysLocked(bankKeys.pinned); err != nil {
+			delete(bankKeys.pinned, name)
+			log.Printf("Gateway: Could not pin key of bank %s: %v", name, err)
+			return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to record bank identity").Err()
+		}
+		audit("bank_key_pinned", "", peerAddr, fmt.Sprintf("bank %s key sha256:%s", name, key))
+	} else if pinned != key {
+		return nil, reject("key sha256:%s differs from the key the bank registered with", key)
+	}
+
+	bankRegistryMutex.Lock()
+	old, known := bankRegistry[name]
+	bankRegistry[name] = req.BankAddress
+	bankRegistryMutex.Unlock()
+	switch {
+	case !known:
+		audit("bank_registered", "", peerAddr, fmt.Sprintf("bank %s at %s", name, req.BankAddress))
+	case old != req.BankAddress:
+		audit("bank_address_changed", "", peerAddr, fmt.Sprintf("bank %s moved from %s to %s", name, old, req.BankAddress))
+	default:
+		log.Printf("Gateway: Bank '%s' re-registered at address %s", name, req.BankAddress)
+	}
+	return &pb.BankRegisterResponse{Success: true, Message: "Bank registration successful"}, nil
+}
+
+// forgetBank drops a bank from the registry and unpins its key, so that it
+// can register again with a new key. It reports whether the bank was known.
+func forgetBank(name string) (bool, error) {
+	bankKeys.Lock()
+	defer bankKeys.Unlock()
+	bankRegistryMutex.Lock()
+	_, registered := bankRegistry[name]
+	delete(bankRegistry, name)
+	bankRegistryMutex.Unlock()
+	key, pinned := bankKeys.pinned[name]
+	if pinned {
+		delete(bankKeys.pinned, name)
+		if err := writeBankKeysLocked(bankKeys.pinned); err != nil {
+			bankKeys.pinned[name] = key
+			return registered, err
+		}
+	}
+	if registered || pinned {
+		audit("bank_forgotten", "", "", fmt.Sprintf("bank %s removed and key unpinned by administrator", name))
+	}
+	return registered || pinned, nil
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/money"
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+	"payment_gateway/txnkey"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// Coordinator log states. A transfer moves through
+// [queued ->] pending -> prepared -> commit-decided|abort-decided -> done,
+// or ends as failed when it never got a yes vote from the sender bank. Anything decided but not
+// done at startup is re-driven until every participant acknowledges, and
+// anything undecided is presumed aborted.
+const (
+	stateQueued       = "queued" // accepted by SubmitTransfer, waiting for a worker
+	statePending      = "pending"
+	statePrepared     = "prepared"
+	stateCommitDecide = "commit-decided"
+	stateAbortDecide  = "abort-decided"
+	stateDone         = "done"
+	stateFailed       = "failed"
+)
+
+// Per-leg events, logged as "<leg>-<outcome>" (e.g. debit-prepared).
+const (
+	legDebit    = "debit"
+	legCredit   = "credit"
+	legPrepared = "prepared"
+	legFailed   = "failed"
+	legAcked    = "acked"
+)
+
+const coordinatorLogFile = "../gateway_txn_log.txt"
+
+// legProgress is what the coordinator knows about one participant.
+type legProgress struct {
+	state   pb.LegState
+	detail  string
+	updated time.Time
+	acked   bool // the participant acknowledged the decision
+}
+
+// coordinatorRecord is the latest known state of one distributed transfer.
+type coordinatorRecord struct {
+	txnID       string
+	state       string
+	decision    string // stateCommitDecide or stateAbortDecide once decided
+	fromBank    string
+	fromAddr    string
+	fromAccount string
+	toBank      string
+	toAddr      string
+	toAccount   string
+	amount      *pb.Money
+	created     time.Time
+	updated     time.Time
+	detail      string    // reason for a failed transfer
+	deadline    time.Time // prepare deadline handed to the banks; not logged
+	debit       legProgress
+	credit      legProgress
+
+	// driving serializes delivery of the decision between TransferMoney
+	// and the recovery loop.
+	driving sync.Mutex
+}
+
+func (rec *coordinatorRecord) leg(name string) *legProgress {
+	if name == legDebit {
+		return &rec.debit
+	}
+	return &rec.credit
+}
+
+// splitLegEvent recognizes "<leg>-<outcome>" log states.
+func splitLegEvent(state string) (string, string, bool) {
+	for _, leg := range []string{legDebit, legCredit} {
+		if outcome, ok := strings.CutPrefix(state, leg+"-"); ok {
+			switch outcome {
+			case legPrepared, legFailed, legAcked:
+				return leg, outcome, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// apply folds one log entry into the record.
+func (rec *coordinatorRecord) apply(state, detail string, at time.Time) {
+	rec.updated = at
+	if leg, outcome, ok := splitLegEvent(state); ok {
+		progress := rec.leg(leg)
+		switch outcome {
+		case legPrepared:
+			progress.state = pb.LegState_LEG_PREPARED
+		case legFailed:
+			progress.state = pb.LegState_LEG_FAILED
+		case legAcked:
+			progress.acked = true
+			if progress.state != pb.LegState_LEG_FAILED {
+				progress.state = pb.LegState_LEG_ABORTED
+				if rec.decision == stateCommitDecide {
+					progress.state = pb.LegState_LEG_COMMITTED
+				}
+			}
+		}
+		if detail != "" {
+			progress.detail = detail
+		}
+		progress.updated = at
+		return
+	}
+	rec.state = state
+	switch state {
+	case stateQueued, statePending:
+		if rec.created.IsZero() {
+			rec.created = at
+		}
+		rec.debit = legProgress{state: pb.LegState_LEG_PENDING, updated: at}
+		rec.credit = legProgress{state: pb.LegState_LEG_PENDING, updated: at}
+	case stateCommitDecide, stateAbortDecide:
+		rec.decision = state
+	case stateFailed:
+		rec.detail = detail
+		// The receiver is never asked to prepare once the sender refuses.
+		for _, progress := range []*legProgress{&rec.debit, &rec.credit} {
+			if progress.state == pb.LegState_LEG_PENDING {
+				progress.state = pb.LegState_LEG_ABORTED
+				progress.updated = at
+			}
+		}
+	}
+}
+
+// inFlight holds transactions whose prepare phase is running but which have
+// not been logged yet, so an in-doubt participant is told to wait instead of
+// being handed a presumed abort.
+var inFlight = struct {
+	sync.Mutex
+	txns map[string]bool
+}{txns: make(map[string]bool)}
+
+// beginInFlight claims txnID for one TransferMoney call; it reports false if
+// another call already holds it.
+func beginInFlight(txnID string) bool {
+	inFlight.Lock()
+	defer inFlight.Unlock()
+	if inFlight.txns[txnID] {
+		return false
+	}
+	inFlight.txns[txnID] = true
+	return true
+}
+
+func endInFlight(txnID string) {
+	inFlight.Lock()
+	delete(inFlight.txns, txnID)
+	inFlight.Unlock()
+}
+
+func isInFlight(txnID string) bool {
+	inFlight.Lock()
+	defer inFlight.Unlock()
+	return inFlight.txns[txnID]
+}
+
+var coordinatorLog = struct {
+	sync.Mutex
+	file    *os.File
+	records map[string]*coordinatorRecord
+}{records: make(map[string]*coordinatorRecord)}
+
+// openCoordinatorLog replays the coordinator log into memory and keeps the
+// file open for appending. Transactions that never reached a decision are
+// presumed aborted.
+func openCoordinatorLog() error {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	f, err := os.OpenFile(coordinatorLogFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	count := 0
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// A torn final line from a crash mid-append is ignored.
+			log.Printf("Gateway: Stopped reading coordinator log at malformed entry: %v", err)
+			break
+		}
+		if err := replayCoordinatorEntryLocked(fields); err != nil {
+			log.Printf("Gateway: Skipping coordinator log entry: %v", err)
+			continue
+		}
+		count++
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	coordinatorLog.file = f
+	log.Printf("Gateway: Replayed %d coordinator log entries", count)
+
+	for _, rec := range coordinatorLog.records {
+		if rec.decision == "" && (rec.state == statePending || rec.state == statePrepared) {
+			log.Printf("Gateway: Txn %s was %s but undecided at shutdown; presuming abort", rec.txnID, rec.state)
+			if err := appendCoordinatorEntryLocked(rec, stateAbortDecide, "presumed abort after restart"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// replayCoordinatorEntryLocked applies one log line. Lines are
+// txn,state,fromBank,fromAddr,fromAccount,toBank,toAddr,toAccount,amount,time
+// with an optional trailing detail.
+func replayCoordinatorEntryLocked(fields []string) error {
+	if len(fields) != 10 && len(fields) != 11 {
+		return fmt.Errorf("expected 10 or 11 fields, got %d", len(fields))
+	}
+	state := fields[1]
+	switch state {
+	case stateQueued, statePending, statePrepared, stateCommitDecide, stateAbortDecide, stateDone, stateFailed:
+	default:
+		if _, _, ok := splitLegEvent(state); !ok {
+			return fmt.Errorf("unknown state %q", state)
+		}
+	}
+	amount, err := money.Parse(fields[8])
+	if err != nil {
+		// Entries written before amounts carried a currency.
+		amount, err = money.ParseDecimal(fields[8], money.DefaultCurrency)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid amount %q: %v", fields[8], err)
+	}
+	at, err := time.Parse(time.RFC3339Nano, fields[9])
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q: %v", fields[9], err)
+	}
+	detail := ""
+	if len(fields) == 11 {
+		detail = fields[10]
+	}
+	rec, ok := coordinatorLog.records[fields[0]]
+	if !ok || state == stateQueued || state == statePending {
+		// A queued or pending entry starts a fresh attempt for the id.
+		prev := rec
+		rec = &coordinatorRecord{
+			txnID:       fields[0],
+			fromBank:    fields[2],
+			fromAddr:    fields[3],
+			fromAccount: fields[4],
+			toBank:      fields[5],
+			toAddr:      fields[6],
+			toAccount:   fields[7],
+			amount:      amount,
+		}
+		if prev != nil && prev.state == stateQueued {
+			rec.created = prev.created
+		}
+		coordinatorLog.records[rec.txnID] = rec
+	}
+	rec.apply(state, detail, at)
+	return nil
+}
+
+// appendCoordinatorEntryLocked forces a state transition to disk before it
+// is applied in memory. The caller must hold coordinatorLog.
+func appendCoordinatorEntryLocked(rec *coordinatorRecord, state, detail string) error {
+	now := time.Now().UTC()
+	writer := csv.NewWriter(coordinatorLog.file)
+	err := writer.Write([]string{
+		rec.txnID, state,
+		rec.fromBank, rec.fromAddr, rec.fromAccount,
+		rec.toBank, rec.toAddr, rec.toAccount,
+		money.Format(rec.amount),
+		now.Format(time.RFC3339Nano),
+		detail,
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return err
+	}
+	if err := coordinatorLog.file.Sync(); err != nil {
+		return err
+	}
+	if prev, ok := coordinatorLog.records[rec.txnID]; ok && prev != rec && prev.state == stateQueued {
+		rec.created = prev.created
+	}
+	rec.apply(state, detail, now)
+	coordinatorLog.records[rec.txnID] = rec
+	notifyTransferWatchers(rec.txnID)
+	return nil
+}
+
+func logCoordinatorState(rec *coordinatorRecord, state string) error {
+	return logCoordinatorEntry(rec, state, "")
+}
+
+// logLegEvent records a participant's vote or acknowledgement.
+func logLegEvent(rec *coordinatorRecord, leg, outcome, detail string) error {
+	return logCoordinatorEntry(rec, leg+"-"+outcome, detail)
+}
+
+func logCoordinatorEntry(rec *coordinatorRecord, state, detail string) error {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	if err := appendCoordinatorEntryLocked(rec, state, detail); err != nil {
+		log.Printf("Gateway: Failed to log %s for txn %s: %v", state, rec.txnID, err)
+		return err
+	}
+	return nil
+}
+
+// lookupDecision reports the coordinator's outcome for txnID. Unknown
+// transactions that are not in flight are presumed aborted.
+func lookupDecision(txnID string) pb.TransactionDecision {
+	if isInFlight(txnID) {
+		return pb.TransactionDecision_DECISION_PENDING
+	}
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	rec, ok := coordinatorLog.records[txnID]
+	if !ok || rec.state == stateFailed {
+		return pb.TransactionDecision_DECISION_ABORT
+	}
+	switch rec.decision {
+	case stateCommitDecide:
+		return pb.TransactionDecision_DECISION_COMMIT
+	case stateAbortDecide:
+		return pb.TransactionDecision_DECISION_ABORT
+	}
+	return pb.TransactionDecision_DECISION_PENDING
+}
+
+// GetTransactionDecision answers a participant bank in doubt. It needs no
+// token: the caller must present the certificate, and the pinned key, of
+// the bank it names, and that bank must take part in the transaction, so
+// clients cannot probe the outcome of transfers.
+func (s *server) GetTransactionDecision(ctx context.Context, req *pb.TransactionDecisionRequest) (*pb.TransactionDecisionResponse, error) {
+	txnID := req.TransactionId
+	if err := checkParticipant(ctx, req.BankName, txnID); err != nil {
+		log.Printf("Gateway: Decision on txn %s refused to '%s': %s", txnID, req.BankName, status.Convert(err).Message())
+		return nil, err
+	}
+	decision := lookupDecision(txnID)
+	log.Printf("Gateway: Bank '%s' asked for decision on txn %s: %s", req.BankName, txnID, decision)
+	return &pb.TransactionDecisionResponse{Decision: decision, Message: fmt.Sprintf("Decision for %s", txnID)}, nil
+}
+
+// checkParticipant checks that the caller connected with the certificate of
+// bankName and that bankName takes part in txnID. A transaction without a
+// record passes, since a bank may hold a prepare the coordinator has lost.
+func checkParticipant(ctx context.Context, bankName, txnID string) error {
+	name, key, err := bankIdentity(ctx)
+	if err == nil && name != bankName {
+		err = fmt.Errorf("certificate is for bank %q", name)
+	}
+	if err == nil {
+		bankKeys.Lock()
+		if pinned, ok := bankKeys.pinned[name]; ok && pinned != key {
+			err = fmt.Errorf("key sha256:%s differs from the key the bank registered with", key)
+		}
+		bankKeys.Unlock()
+	}
+	if err == nil {
+		coordinatorLog.Lock()
+		if rec, ok := coordinatorLog.records[txnID]; ok && rec.fromBank != bankName && rec.toBank != bankName {
+			err = fmt.Errorf("bank %q is not a participant", bankName)
+		}
+		coordinatorLog.Unlock()
+	}
+	if err != nil {
+		return payerr.New(pb.ErrorReason_BANK_IDENTITY_MISMATCH, "Decision request rejected: "+err.Error()).Err()
+	}
+	return nil
+}
+
+// hasDecision reports whether the coordinator has logged a decision for txnID.
+func hasDecision(txnID string) bool {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	rec, ok := coordinatorLog.records[txnID]
+	return ok && rec.decision != ""
+}
+
+// unfinishedTransactions returns every decided transaction that still has
+// unacknowledged participants.
+func unfinishedTransactions() []*coordinatorRecord {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	var pending []*coordinatorRecord
+	for _, rec := range coordinatorLog.records {
+		if rec.state == stateCommitDecide || rec.state == stateAbortDecide {
+			pending = append(pending, rec)
+		}
+	}
+	return pending
+}
+
+// resolveBankAddress prefers the live registry and falls back to the address
+// recorded when the transaction was prepared, so recovery can make progress
+// before the banks re-register.
+func resolveBankAddress(bankName, loggedAddr string) string {
+	if addr, ok := getBankAddress(bankName); ok {
+		return addr
+	}
+	return loggedAddr
+}
+
+func dialBank(addr string) (*grpc.ClientConn, pb.BankClient, error) {
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, pb.NewBankClient(conn), nil
+}
+
+func unixMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func (rec *coordinatorRecord) debitRequest() *pb.DebitCreditRequest {
+	return &pb.DebitCreditRequest{
+		AccountId:             rec.fromAccount,
+		Amount:                rec.amount,
+		TransactionId:         rec.txnID,
+		Key:                   txnkey.New(rec.txnID, pb.LegType_LEG_DEBIT, 0),
+		CounterpartyAccount:   rec.toAccount,
+		PrepareDeadlineUnixMs: unixMillis(rec.deadline),
+	}
+}
+
+func (rec *coordinatorRecord) creditRequest() *pb.DebitCreditRequest {
+	return &pb.DebitCreditRequest{
+		AccountId:             rec.toAccount,
+		Amount:                rec.amount,
+		TransactionId:         rec.txnID,
+		Key:                   txnkey.New(rec.txnID, pb.LegType_LEG_CREDIT, 0),
+		CounterpartyAccount:   rec.fromAccount,
+		PrepareDeadlineUnixMs: unixMillis(rec.deadline),
+	}
+}
+
+// driveDecision delivers the logged decision to every participant that has
+// not yet acknowledged it and logs done once both have. It reports whether
+// the transaction is finished.
+func driveDecision(ctx context.Context, rec *coordinatorRecord) bool {
+	if !rec.driving.TryLock() {
+		return false
+	}
+	defer rec.driving.Unlock()
+	decision, debitAcked, creditAcked := decisionProgress(rec)
+	if !debitAcked && deliverDecision(ctx, rec, rec.fromBank, rec.fromAddr, legDebit, decision) {
+		debitAcked = logLegEvent(rec, legDebit, legAcked, "") == nil
+	}
+	if !creditAcked && deliverDecision(ctx, rec, rec.toBank, rec.toAddr, legCredit, decision) {
+		creditAcked = logLegEvent(rec, legCredit, legAcked, "") == nil
+	}
+	if !debitAcked || !creditAcked {
+		return false
+	}
+	if err := logCoordinatorState(rec, stateDone); err != nil {
+		return false
+	}
+	log.Printf("Gateway: Txn %s finished (%s acknowledged by all participants)", rec.txnID, decision)
+	return true
+}
+
+// decisionProgress returns the decision logged for rec and which legs have
+// acknowledged it, read under the coordinator log's lock.
+func decisionProgress(rec *coordinatorRecord) (decision string, debitAcked, creditAcked bool) {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	return rec.decision, rec.debit.acked, rec.credit.acked
+}
+
+func deliverDecision(ctx context.Context, rec *coordinatorRecord, bankName, loggedAddr, leg, decision string) bool {
+	commit := decision == stateCommitDecide
+	addr := resolveBankAddress(bankName, loggedAddr)
+	conn, client, err := dialBank(addr)
+	if err != nil {
+		log.Printf("Gateway: Txn %s: cannot reach %s bank %s at %s: %v", rec.txnID, leg, bankName, addr, err)
+		return false
+	}
+	defer conn.Close()
+	switch {
+	case leg == legDebit && commit:
+		_, err = client.CommitDebit(ctx, rec.debitRequest())
+	case leg == legDebit:
+		_, err = client.AbortDebit(ctx, rec.debitRequest())
+	case commit:
+		_, err = client.CommitCredit(ctx, rec.creditRequest())
+	default:
+		_, err = client.AbortCredit(ctx, rec.creditRequest())
+	}
+	if err == nil {
+		return true
+	}
+	// The decision is final, so it is redelivered either way; a refusal
+	// that retrying cannot fix (e.g. no matching hold) needs an operator.
+	failure := bankFailure(bankName, err)
+	if failure.Retryable {
+		log.Printf("Gateway: Txn %s: %s %s not acknowledged by %s (%s): %s", rec.txnID, decision, leg, bankName, failure.Reason, failure.Message)
+	} else {
+		log.Printf("Gateway: Txn %s: %s %s rejected by %s (%s): %s; manual resolution may be needed", rec.txnID, decision, leg, bankName, failure.Reason, failure.Message)
+	}
+	return false
+}
+
+// recoverUnfinishedTransactions re-drives every decided but unfinished
+// transaction until all participants acknowledge.
+func recoverUnfinishedTransactions(interval time.Duration) {
+	for {
+		pending := unfinishedTransactions()
+		for _, rec := range pending {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			driveDecision(ctx, rec)
+			cancel()
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/money"
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+)
+
+const idempotencyFile = "../gateway_idempotency.txt"
+
+// storedTransfer is the final reply given for a transaction id, together
+// with the fingerprint of the request that produced it. Exactly one of
+// response and err is set.
+type storedTransfer struct {
+	fingerprint string
+	response    *pb.TransferResponse
+	err         error
+}
+
+var idempotencyStore = struct {
+	sync.RWMutex
+	file      *os.File
+	responses map[string]storedTransfer // transaction id -> final reply
+}{responses: make(map[string]storedTransfer)}
+
+// transferFingerprint identifies what a TransferRequest asks for, so reuse
+// of a transaction id for a different transfer can be told apart from a
+// retry of the same one.
+func transferFingerprint(req *pb.TransferRequest) string {
+	return fingerprintOf(req.FromBank, req.FromAccount, req.ToBank, req.ToAccount, req.Amount)
+}
+
+func fingerprintOf(fromBank, fromAccount, toBank, toAccount string, amount *pb.Money) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		fromBank, fromAccount, toBank, toAccount,
+		money.Format(amount),
+	}, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// openIdempotencyStore loads stored replies and keeps the file open for
+// appending.
+func openIdempotencyStore() error {
+	idempotencyStore.Lock()
+	defer idempotencyStore.Unlock()
+	f, err := os.OpenFile(idempotencyFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Gateway: Stopped reading idempotency store at malformed entry: %v", err)
+			break
+		}
+		// txn_id,fingerprint,success,message,stored_at[,reason,bank_name]
+		if len(fields) != 5 && len(fields) != 7 {
+			continue
+		}
+		stored := storedTransfer{fingerprint: fields[1]}
+		if fields[2] == "true" {
+			stored.response = &pb.TransferResponse{Success: true, Message: fields[3]}
+		} else {
+			// Entries written before failures carried a reason were all
+			// aborted transfers.
+			reason, bankName := pb.ErrorReason_TRANSACTION_ABORTED, ""
+			if len(fields) == 7 {
+				reason = pb.ErrorReason(pb.ErrorReason_value[fields[5]])
+				bankName = fields[6]
+			}
+			stored.err = finalTransferError(reason, bankName, fields[3])
+		}
+		idempotencyStore.responses[fields[0]] = stored
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	idempotencyStore.file = f
+	log.Printf("Gateway: Loaded %d stored transfer results", len(idempotencyStore.responses))
+	return nil
+}
+
+// lookupTransfer returns the stored reply for txnID, falling back to the
+// coordinator log for transfers whose reply was lost in a crash after the
+// decision was logged.
+func lookupTransfer(txnID string) (storedTransfer, bool) {
+	idempotencyStore.RLock()
+	stored, ok := idempotencyStore.responses[txnID]
+	idempotencyStore.RUnlock()
+	if ok {
+		return stored, true
+	}
+	coordinatorLog.Lock()
+	rec, ok := coordinatorLog.records[txnID]
+	var decision string
+	if ok {
+		decision = rec.decision
+	}
+	coordinatorLog.Unlock()
+	if decision == "" {
+		return storedTransfer{}, false
+	}
+	stored = storedTransfer{
+		fingerprint: fingerprintOf(rec.fromBank, rec.fromAccount, rec.toBank, rec.toAccount, rec.amount),
+	}
+	if decision == stateCommitDecide {
+		stored.response = &pb.TransferResponse{Success: true, Message: "Transaction processed successfully"}
+	} else {
+		stored.err = finalTransferError(pb.ErrorReason_TRANSACTION_ABORTED, "", "Transaction was aborted.")
+	}
+	return stored, true
+}
+
+// finalTransferError is the error replayed for a transaction id whose
+// transfer failed after a decision. It is never retryable: sending the same
+// request again only returns it again.
+func finalTransferError(reason pb.ErrorReason, bankName, msg string) error {
+	failure := payerr.New(reason, msg)
+	failure.Retryable = false
+	failure.BankName = bankName
+	return failure.Err()
+}
+
+// storeTransfer persists the final reply for txnID and returns it as it
+// will be replayed. Only replies given once the coordinator reached a
+// decision are stored; earlier failures leave the id free so the client can
+// retry it.
+func storeTransfer(txnID, fingerprint string, resp *pb.TransferResponse, transferErr error) (*pb.TransferResponse, error) {
+	stored := storedTransfer{fingerprint: fingerprint, response: resp}
+	fields := []string{txnID, fingerprint, "true", resp.GetMessage(), time.Now().UTC().Format(time.RFC3339Nano), "", ""}
+	if transferErr != nil {
+		failure := payerr.From(transferErr)
+		stored = storedTransfer{
+			fingerprint: fingerprint,
+			err:         finalTransferError(failure.Reason, failure.BankName, failure.Message),
+		}
+		fields[2], fields[3], fields[5], fields[6] = "false", failure.Message, failure.Reason.String(), failure.BankName
+	}
+	idempotencyStore.Lock()
+	defer idempotencyStore.Unlock()
+	writer := csv.NewWriter(idempotencyStore.file)
+	err := writer.Write(fields)
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err == nil {
+		err = idempotencyStore.file.Sync()
+	}
+	if err != nil {
+		log.Printf("Gateway: Failed to store result for txn %s: %v", txnID, err)
+	} else {
+		idempotencyStore.responses[txnID] = stored
+	}
+	return stored.response, stored.err
+}
@@ -5,8 +5,12 @@ import (
 	"fmt"
 	"log"
 
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -22,30 +26,115 @@ func AuthInterceptor(
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
 	if !isGatewayActive() {
-		return nil, fmt.Errorf("Gateway is offline")
+		return nil, errGatewayOffline()
 	}
 	log.Printf(ColorMagenta+"----- Start Request: %s -----"+ColorReset, info.FullMethod)
+	// BankRegister and GetTransactionDecision need no token: the bank is
+	// identified by its certificate instead (see bankIdentity).
 	if info.FullMethod == "/payment.PaymentGateway/Register" ||
 		info.FullMethod == "/payment.PaymentGateway/Authenticate" ||
-		info.FullMethod == "/payment.PaymentGateway/BankRegister" {
+		info.FullMethod == "/payment.PaymentGateway/BankRegister" ||
+		info.FullMethod == "/payment.PaymentGateway/GetTransactionDecision" ||
+		info.FullMethod == "/payment.PaymentGateway/RefreshToken" {
 		log.Printf(ColorYellow+"Interceptor: Skipping auth for method: %s"+ColorReset, info.FullMethod)
 		resp, err := handler(ctx, req)
 		log.Printf(ColorMagenta+"----- End Request: %s -----"+ColorReset, info.FullMethod)
 		return resp, err
 	}
+	caller, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkOwnership(caller, req); err != nil {
+		log.Printf(ColorYellow+"Interceptor: %s denied for user %s: %s"+ColorReset, info.FullMethod, caller.username, status.Convert(err).Message())
+		return nil, err
+	}
+	log.Printf(ColorYellow+"Interceptor: Token validated for method: %s"+ColorReset, info.FullMethod)
+	resp, err := handler(context.WithValue(ctx, callerKey{}, caller), req)
+	log.Printf(ColorMagenta+"----- End Request: %s -----"+ColorReset, info.FullMethod)
+	return resp, err
+}
+
+// AuthStreamInterceptor applies the token check to streaming RPCs.
+func AuthStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if !isGatewayActive() {
+		return errGatewayOffline()
+	}
+	log.Printf(ColorMagenta+"----- Start Stream: %s -----"+ColorReset, info.FullMethod)
+	caller, err := authorize(ss.Context())
+	if err != nil {
+		return err
+	}
+	log.Printf(ColorYellow+"Interceptor: Token validated for stream: %s"+ColorReset, info.FullMethod)
+	err = handler(srv, &authenticatedStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), callerKey{}, caller)})
+	log.Printf(ColorMagenta+"----- End Stream: %s -----"+ColorReset, info.FullMethod)
+	return err
+}
+
+// authorize returns what the request's token was issued for.
+func authorize(ctx context.Context) (tokenInfo, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return nil, fmt.Errorf("Missing metadata")
+		return tokenInfo{}, payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Missing metadata").Err()
 	}
 	tokens := md["authorization"]
 	if len(tokens) == 0 {
-		return nil, fmt.Errorf("Missing authorization token")
+		return tokenInfo{}, payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Missing authorization token").Err()
+	}
+	return verifyToken(tokens[0])
+}
+
+// callerKey is the context key under which handlers find the tokenInfo of
+// an authorized request.
+type callerKey struct{}
+
+// callerFrom returns the token holder of an authorized request.
+func callerFrom(ctx context.Context) tokenInfo {
+	caller, _ := ctx.Value(callerKey{}).(tokenInfo)
+	return caller
+}
+
+// authenticatedStream hands the caller to streaming handlers.
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// checkOwnership rejects requests that act on an account the caller's
+// token does not cover. Requests naming a transfer rather than an account
+// are checked by their handlers.
+func checkOwnership(caller tokenInfo, req interface{}) error {
+	switch r := req.(type) {
+	case *pb.TransferRequest:
+		return requireOwner(caller, r.FromBank, r.FromAccount)
+	case *pb.BalanceRequest:
+		return requireOwner(caller, r.BankName, r.AccountId)
+	case *pb.AccountRequest:
+		return requireOwner(caller, r.BankName, r.AccountId)
 	}
-	if !ValidateToken(tokens[0]) {
-		return nil, fmt.Errorf("Invalid token")
+	return nil
+}
+
+// requireOwner checks that the token covers the account and that it is still
+// linked to the caller; a closed account may since have been reopened for
+// someone else.
+func requireOwner(caller tokenInfo, bank, account string) error {
+	if caller.owns(bank, account) && ownerOf(bank, account) == caller.username {
+		return nil
 	}
-	log.Printf(ColorYellow+"Interceptor: Token validated for method: %s"+ColorReset, info.FullMethod)
-	resp, err := handler(ctx, req)
-	log.Printf(ColorMagenta+"----- End Request: %s -----"+ColorReset, info.FullMethod)
-	return resp, err
+	return payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED,
+		fmt.Sprintf("account %s at %s is not covered by the token of user %s", account, bank, caller.username)).Err()
+}
+
+func errGatewayOffline() error {
+	return payerr.New(pb.ErrorReason_GATEWAY_UNAVAILABLE, "Gateway is offline").Err()
 }
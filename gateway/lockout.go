@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+
+	"google.golang.org/grpc/peer"
+)
+
+var (
+	maxFailedLogins        = flag.Int("max-failed-logins", 5, "Failed logins for one username before it is locked out")
+	maxFailedLoginsPerPeer = flag.Int("max-failed-logins-per-peer", 20, "Failed logins from one client address before it is locked out")
+	lockoutDuration        = flag.Duration("lockout-duration", 15*time.Minute, "How long a lockout lasts unless lifted with 'unlock'")
+	loginDelayBase         = flag.Duration("login-delay-base", 500*time.Millisecond, "Wait required after the first failed login; doubles with each further failure")
+	loginDelayMax          = flag.Duration("login-delay-max", 30*time.Second, "Longest wait required between failed logins")
+	loginFailureWindow     = flag.Duration("login-failure-window", 15*time.Minute, "Failed logins older than this are forgotten")
+)
+
+// loginAttempts counts recent failed logins for one username or peer.
+type loginAttempts struct {
+	failures    int
+	reserved    int // attempts admitted but not yet decided
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// delay is how long after the last failure the next attempt must wait.
+func (a *loginAttempts) delay() time.Duration {
+	if a.failures == 0 {
+		return 0
+	}
+	d := *loginDelayBase
+	for i := 1; i < a.failures && d < *loginDelayMax; i++ {
+		d *= 2
+	}
+	if d > *loginDelayMax {
+		d = *loginDelayMax
+	}
+	return d
+}
+
+// loginGuard tracks failed logins by username and by client address, so
+// that guessing one user's password and trying one password on many users
+// are both slowed down.
+var loginGuard = struct {
+	sync.Mutex
+	users map[string]*loginAttempts
+	peers map[string]*loginAttempts
+}{users: make(map[string]*loginAttempts), peers: make(map[string]*loginAttempts)}
+
+// peerHost returns the address of the caller without its port.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return host
+	}
+	return p.Addr.String()
+}
+
+// pruneAfter is the table size above which stale counters are swept.
+const pruneAfter = 10000
+
+// stale reports whether a holds nothing worth keeping: no attempt in
+// progress, no lockout in force and no failure within the window.
+func (a *loginAttempts) stale(now time.Time) bool {
+	if a.reserved > 0 {
+		return false
+	}
+	if !a.lockedUntil.IsZero() {
+		return !now.Before(a.lockedUntil)
+	}
+	return now.Sub(a.lastFailure) > *loginFailureWindow
+}
+
+// attemptsLocked returns the counters for key, forgetting old failures and
+// expired lockouts. With create unset, an unknown key gets a zero value that
+// is not stored. The caller must hold the lock guarding table.
+func attemptsLocked(table map[string]*loginAttempts, key string, now time.Time, create bool) *loginAttempts {
+	a, ok := table[key]
+	if ok && a.stale(now) {
+		delete(table, key)
+		ok = false
+	}
+	if !ok {
+		a = &loginAttempts{}
+		if create {
+			if len(table) >= pruneAfter {
+				for k, other := range table {
+					if other.stale(now) {
+						delete(table, k)
+					}
+				}
+			}
+			table[key] = a
+		}
+	}
+	return a
+}
+
+// loginAttempt is a password or one-time code check admitted by
+// checkLogin. Until it ends it is reserved against the limits of both the
+// username and the peer, so checks running in parallel cannot between them
+// exceed the limits.
+type loginAttempt struct {
+	username string
+	peerAddr string
+	done     bool
+}
+
+// checkLogin rejects a login attempt for username from peerAddr while
+// either is locked out, has not waited out its delay or has as many
+// attempts in progress as it has failures left, and otherwise admits it. It
+// is called before the password is checked, so a correct password does not
+// get through a lockout. The caller ends the attempt with fail or succeed;
+// release, which is safe to defer, ends one that has neither.
+func checkLogin(username, peerAddr string) (*loginAttempt, error) {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	now := time.Now()
+	for _, c := range []struct {
+		what  string
+		a     *loginAttempts
+		limit int
+	}{
+		{"user " + username, attemptsLocked(loginGuard.users, username, now, false), *maxFailedLogins},
+		{"address " + peerAddr, attemptsLocked(loginGuard.peers, peerAddr, now, false), *maxFailedLoginsPerPeer},
+	} {
+		if now.Before(c.a.lockedUntil) {
+			failure := payerr.New(pb.ErrorReason_LOGIN_LOCKED_OUT,
+				fmt.Sprintf("Logins for %s are locked until %s", c.what, c.a.lockedUntil.Format(time.RFC3339)))
+			failure.RetryDelay = c.a.lockedUntil.Sub(now)
+			return nil, failure.Err()
+		}
+		if wait := c.a.lastFailure.Add(c.a.delay()).Sub(now); wait > 0 {
+			failure := payerr.New(pb.ErrorReason_LOGIN_THROTTLED,
+				fmt.Sprintf("Too many failed logins for %s; try again in %s", c.what, wait.Round(time.Millisecond)))
+			failure.RetryDelay = wait
+			return nil, failure.Err()
+		}
+		if c.a.failures+c.a.reserved >= c.limit {
+			failure := payerr.New(pb.ErrorReason_LOGIN_THROTTLED,
+				fmt.Sprintf("Too many logins for %s are being checked at once; try again shortly", c.what))
+			failure.RetryDelay = *loginDelayBase
+			return nil, failure.Err()
+		}
+	}
+	attemptsLocked(loginGuard.users, username, now, true).reserved++
+	attemptsLocked(loginGuard.peers, peerAddr, now, true).reserved++
+	return &loginAttempt{username: username, peerAddr: peerAddr}, nil
+}
+
+// unreserveLocked ends a reservation on the counters for key, if they are
+// still kept, and returns them.
+func unreserveLocked(table map[string]*loginAttempts, key string) *loginAttempts {
+	a := table[key]
+	if a != nil && a.reserved > 0 {
+		a.reserved--
+	}
+	return a
+}
+
+// clearFailuresLocked forgets the failures and lockout of key. Attempts
+// still in progress keep their reservations.
+func clearFailuresLocked(table map[string]*loginAttempts, key string) {
+	a := table[key]
+	if a == nil {
+		return
+	}
+	if a.reserved == 0 {
+		delete(table, key)
+		return
+	}
+	*a = loginAttempts{reserved: a.reserved}
+}
+
+// fail counts the attempt as a failed login and locks out the username or
+// peer that reaches its threshold.
+func (a *loginAttempt) fail() {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	if a.done {
+		return
+	}
+	a.done = true
+	unreserveLocked(loginGuard.users, a.username)
+	unreserveLocked(loginGuard.peers, a.peerAddr)
+	now := time.Now()
+	user := attemptsLocked(loginGuard.users, a.username, now, true)
+	user.failures++
+	user.lastFailure = now
+	if user.failures >= *maxFailedLogins && user.lockedUntil.IsZero() {
+		user.lockedUntil = now.Add(*lockoutDuration)
+		audit("lockout", a.username, a.peerAddr, fmt.Sprintf("user locked for %s after %d failed logins", *lockoutDuration, user.failures))
+	}
+	p := attemptsLocked(loginGuard.peers, a.peerAddr, now, true)
+	p.failures++
+	p.lastFailure = now
+	if p.failures >= *maxFailedLoginsPerPeer && p.lockedUntil.IsZero() {
+		p.lockedUntil = now.Add(*lockoutDuration)
+		audit("lockout", a.username, a.peerAddr, fmt.Sprintf("address locked for %s after %d failed logins", *lockoutDuration, p.failures))
+	}
+}
+
+// succeed clears the failures of the username. The peer's count is left to
+// expire, so logging in to one's own account between guesses at others does
+// not reset it.
+func (a *loginAttempt) succeed() {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	if a.done {
+		return
+	}
+	a.done = true
+	unreserveLocked(loginGuard.peers, a.peerAddr)
+	unreserveLocked(loginGuard.users, a.username)
+	clearFailuresLocked(loginGuard.users, a.username)
+}
+
+// release ends an attempt that neither failed nor succeeded, such as one
+// that was missing its one-time code.
+func (a *loginAttempt) release() {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	if a.done {
+		return
+	}
+	a.done = true
+	unreserveLocked(loginGuard.users, a.username)
+	unreserveLocked(loginGuard.peers, a.peerAddr)
+}
+
+// unlockLogin lifts the lockout and clears the failures of a username or
+// client address. It reports whether there was anything to clear.
+func unlockLogin(key string) bool {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	_, user := loginGuard.users[key]
+	_, p := loginGuard.peers[key]
+	delete(loginGuard.users, key)
+	delete(loginGuard.peers, key)
+	if user {
+		audit("unlock", key, "", "user cleared by administrator")
+	}
+	if p {
+		audit("unlock", "", key, "address cleared by administrator")
+	}
+	return user || p
+}
+
+// lockedLogins lists current lockouts, for the 'locks' command.
+func lockedLogins() []string {
+	loginGuard.Lock()
+	defer loginGuard.Unlock()
+	now := time.Now()
+	var locks []string
+	for what, table := range map[string]map[string]*loginAttempts{"user": loginGuard.users, "address": loginGuard.peers} {
+		for key, a := range table {
+			if now.Before(a.lockedUntil) {
+				locks = append(locks, fmt.Sprintf("%s %s locked until %s (%d failures)", what, key, a.lockedUntil.Format(time.RFC3339), a.failures))
+			}
+		}
+	}
+	sort.Strings(locks)
+	return locks
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -15,14 +16,21 @@ import (
 	"sync"
 	"time"
 
+	"payment_gateway/money"
+	"payment_gateway/password"
+	"payment_gateway/payerr"
 	pb "payment_gateway/proto"
+	"payment_gateway/validate"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 var (
-	port = flag.Int("port", 50051, "The gateway server port")
+	port             = flag.Int("port", 50051, "The gateway server port")
+	recoveryInterval = flag.Duration("recovery-interval", 5*time.Second, "How often unfinished 2PC decisions are re-driven")
+	prepareTimeout   = flag.Duration("prepare-timeout", 30*time.Second, "Prepare deadline sent to the banks; no commit is decided after it, and banks refuse one beyond their -max-hold")
 )
 
 // Global gateway status.
@@ -44,17 +52,45 @@ func setGatewayActive(status bool) {
 func monitorGatewayStatus() {
 	scanner := bufio.NewScanner(os.Stdin)
 	for {
-		fmt.Print("Gateway: Enter command (down/up): ")
+		fmt.Print("Gateway: Enter command (down/up/locks/unlock <username|address>/forget-bank <bank>): ")
 		if scanner.Scan() {
-			cmd := strings.ToLower(strings.TrimSpace(scanner.Text()))
+			fields := strings.Fields(scanner.Text())
+			cmd := ""
+			if len(fields) > 0 {
+				cmd = strings.ToLower(fields[0])
+			}
 			if cmd == "down" {
 				setGatewayActive(false)
 				log.Printf("Gateway: Now offline.")
 			} else if cmd == "up" {
 				setGatewayActive(true)
 				log.Printf("Gateway: Now online.")
+			} else if cmd == "locks" {
+				locks := append(lockedLogins(), lockedPins()...)
+				if len(locks) == 0 {
+					log.Printf("Gateway: No logins or PINs are locked out.")
+				}
+				for _, lock := range locks {
+					log.Printf("Gateway: %s", lock)
+				}
+			} else if cmd == "unlock" && len(fields) == 2 {
+				// Usernames are case-sensitive, so the argument is used as typed.
+				logins, pins := unlockLogin(fields[1]), unlockPin(fields[1])
+				if logins || pins {
+					log.Printf("Gateway: Cleared failed logins and PINs of %s.", fields[1])
+				} else {
+					log.Printf("Gateway: No failed logins or PINs recorded for %s.", fields[1])
+				}
+			} else if cmd == "forget-bank" && len(fields) == 2 {
+				if known, err := forgetBank(fields[1]); err != nil {
+					log.Printf("Gateway: Could not forget bank %s: %v", fields[1], err)
+				} else if known {
+					log.Printf("Gateway: Bank %s removed; it may register again with a new key.", fields[1])
+				} else {
+					log.Printf("Gateway: Bank %s is not known.", fields[1])
+				}
 			} else {
-				log.Printf("Gateway: Unknown command. Use 'down' or 'up'.")
+				log.Printf("Gateway: Unknown command. Use 'down', 'up', 'locks', 'unlock <username|address>' or 'forget-bank <bank>'.")
 			}
 		}
 	}
@@ -71,197 +107,449 @@ func getBankAddress(bankName string) (string, bool) {
 	return addr, ok
 }
 
-func (s *server) BankRegister(ctx context.Context, req *pb.BankRegisterRequest) (*pb.BankRegisterResponse, error) {
-	bankRegistryMutex.Lock()
-	bankRegistry[req.BankName] = req.BankAddress
-	bankRegistryMutex.Unlock()
-	log.Printf("Gateway: Bank '%s' registered at address %s", req.BankName, req.BankAddress)
-	return &pb.BankRegisterResponse{Success: true, Message: "Bank registration successful"}, nil
+func isBankRegistered(bankName string) bool {
+	_, ok := getBankAddress(bankName)
+	return ok
 }
 
 type server struct {
 	pb.UnimplementedPaymentGatewayServer
 }
 
-func PrintRegisteredUsers() {
-	userStore.RLock()
-	defer userStore.RUnlock()
-	log.Println("Gateway: Currently registered users:")
-	for username, user := range userStore.users {
-		log.Printf("   Username: %s, Password: %s", username, user.Password)
-	}
-}
-
+// Register creates a gateway user for an account the bank already holds in
+// that user's name, given the account's bank password, or for a new account
+// opened at the bank when open_account is set. Usernames cannot be registered twice; passwords are
+// changed with ChangePassword.
 func (s *server) Register(ctx context.Context, req *pb.RegisterRequest) (*pb.RegisterResponse, error) {
 	log.Printf("Gateway: Register RPC called with username: %s", req.Username)
-	RegisterUser(req.Username, req.Password)
-	err := AppendOrUpdateGatewayUser(req.Username, req.Password)
+	if UserExists(req.Username) {
+		return nil, errUsernameTaken(req.Username)
+	}
+	hash, err := password.Hash(req.Password)
+	if err != nil {
+		log.Printf("Gateway: Registration of %s failed: could not hash password: %v", req.Username, err)
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, "Password cannot be used").Err()
+	}
+	// An existing account is only linked once the bank accepts the password
+	// for it, and guessing that password counts towards the login lockout.
+	attempt, err := checkLogin(req.Username, peerHost(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer attempt.release()
+	var acct *pb.AccountResponse
+	if req.OpenAccount {
+		acct, err = openBankAccount(ctx, req.Username, req.Password, req.BankName, req.AccountId)
+	} else {
+		acct, err = verifyBankAccount(ctx, req.Username, req.Password, req.BankName, req.AccountId)
+	}
+	if payerr.Reason(err) == pb.ErrorReason_INVALID_CREDENTIALS {
+		attempt.fail()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !AddUser(req.Username, hash) {
+		// Lost a race with another registration of the same name. An account
+		// opened for it stays with the bank, held under this username.
+		return nil, errUsernameTaken(req.Username)
+	}
+	if err := linkAccountTo(req.Username, acct); err != nil {
+		RemoveUser(req.Username)
+		return nil, err
+	}
+	err = AppendOrUpdateGatewayUser(req.Username, User{PasswordHash: hash})
 	if err != nil {
+		// The account link stays: the bank holds the account under this
+		// username, so only its holder can register it again.
+		RemoveUser(req.Username)
 		msg := fmt.Sprintf("Failed to update gateway users file: %v", err)
 		log.Printf("Gateway: Registration failed: %s", msg)
-		return &pb.RegisterResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, msg).Err()
 	}
-	log.Printf("Gateway: User %s registered successfully.", req.Username)
-	return &pb.RegisterResponse{Success: true, Message: "Registration successful"}, nil
+	log.Printf("Gateway: User %s registered successfully with account %s at %s.", req.Username, acct.AccountId, acct.BankName)
+	return &pb.RegisterResponse{Success: true, Message: "Registration successful", AccountId: acct.AccountId}, nil
+}
+
+func errUsernameTaken(username string) error {
+	msg := fmt.Sprintf("Username %s is already registered", username)
+	log.Printf("Gateway: Registration rejected: %s", msg)
+	return payerr.New(pb.ErrorReason_USERNAME_TAKEN, msg).Err()
 }
 
 func (s *server) Authenticate(ctx context.Context, req *pb.AuthRequest) (*pb.AuthResponse, error) {
 	log.Printf("Gateway: Authenticate RPC called for username: %s", req.Username)
-	valid := ValidateUser(req.Username, req.Password)
+	peerAddr := peerHost(ctx)
+	attempt, err := checkLogin(req.Username, peerAddr)
+	if err != nil {
+		log.Printf("Gateway: Authentication refused for username: %s from %s: %s", req.Username, peerAddr, status.Convert(err).Message())
+		return nil, err
+	}
+	defer attempt.release()
+	version, valid := ValidateUser(req.Username, req.Password)
 	if !valid {
+		attempt.fail()
 		msg := "Invalid credentials or user not registered"
 		log.Printf("Gateway: Authentication failed for username: %s, message: %s", req.Username, msg)
-		PrintRegisteredUsers()
-		return &pb.AuthResponse{Token: "", Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_INVALID_CREDENTIALS, msg).Err()
+	}
+	if err := checkLoginSecondFactor(attempt, req.OtpCode); err != nil {
+		log.Printf("Gateway: Authentication failed for username: %s, message: %s", req.Username, status.Convert(err).Message())
+		return nil, err
+	}
+	attempt.succeed()
+	// The token covers every account of the user, or only the one named in
+	// the request.
+	accounts := AccountsOf(req.Username)
+	if req.AccountId != "" {
+		var scoped []accountRef
+		for _, ref := range accounts {
+			if ref.account == req.AccountId {
+				scoped = append(scoped, ref)
+			}
+		}
+		if len(scoped) == 0 {
+			msg := fmt.Sprintf("Account %s is not registered to user %s", req.AccountId, req.Username)
+			log.Printf("Gateway: Authentication failed for username: %s, message: %s", req.Username, msg)
+			return nil, payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED, msg).Err()
+		}
+		accounts = scoped
+	}
+	token, info, err := issueToken(req.Username, version, accounts)
+	if err != nil {
+		log.Printf("Gateway: Failed to issue token for %s: %v", req.Username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to issue token").Err()
+	}
+	log.Printf("Gateway: User %s authenticated successfully for %d account(s), token %s issued until %s",
+		req.Username, len(accounts), info.id, info.expires.Format(time.RFC3339))
+	return &pb.AuthResponse{Token: token, Message: "Authentication successful", ExpiresUnixMs: info.expires.UnixMilli()}, nil
+}
+
+// RefreshToken swaps a token for a new one with the same subject. Expired
+// tokens are accepted for -token-refresh-window so idle clients need not
+// log in again; the old token is revoked so it cannot be refreshed twice.
+func (s *server) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.AuthResponse, error) {
+	old, err := parseToken(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Gateway: RefreshToken called for user %s, token %s", old.username, old.id)
+	if isTokenRevoked(old.id) {
+		return nil, payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Token has been revoked").Err()
+	}
+	if time.Since(old.expires) > *refreshWindow {
+		return nil, payerr.New(pb.ErrorReason_TOKEN_EXPIRED, "Token is too old to refresh; authenticate again").Err()
+	}
+	if err := checkTokenVersion(old); err != nil {
+		return nil, err
+	}
+	// Carry over only the accounts the user still owns.
+	var accounts []accountRef
+	for _, ref := range AccountsOf(old.username) {
+		if old.owns(ref.bank, ref.account) {
+			accounts = append(accounts, ref)
+		}
+	}
+	// Revoking is what claims the refresh: a concurrent refresh of the same
+	// token that got past the check above fails here.
+	err = revokeToken(old)
+	if errors.Is(err, errAlreadyRevoked) {
+		return nil, payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Token has been revoked").Err()
+	}
+	if err != nil {
+		log.Printf("Gateway: Failed to revoke token %s: %v", old.id, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to revoke the old token").Err()
+	}
+	token, info, err := issueToken(old.username, old.version, accounts)
+	if err != nil {
+		log.Printf("Gateway: Failed to issue token for %s: %v", old.username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to issue token").Err()
+	}
+	log.Printf("Gateway: Token %s of user %s refreshed as %s until %s", old.id, old.username, info.id, info.expires.Format(time.RFC3339))
+	return &pb.AuthResponse{Token: token, Message: "Token refreshed", ExpiresUnixMs: info.expires.UnixMilli()}, nil
+}
+
+// Logout revokes the token the call was made with.
+func (s *server) Logout(ctx context.Context, req *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	caller := callerFrom(ctx)
+	if err := revokeToken(caller); err != nil && !errors.Is(err, errAlreadyRevoked) {
+		log.Printf("Gateway: Failed to revoke token %s: %v", caller.id, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to revoke token").Err()
+	}
+	log.Printf("Gateway: User %s logged out; token %s revoked", caller.username, caller.id)
+	return &pb.LogoutResponse{Message: "Logged out"}, nil
+}
+
+// ChangePassword sets a new password for the caller once the old one is
+// confirmed. Bumping the password version invalidates every token issued
+// before, including the one the call is made with; a fresh token for the same
+// accounts is returned.
+func (s *server) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.AuthResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: ChangePassword called for user %s", caller.username)
+	// The old password is guessable with a stolen token, so it counts
+	// towards the same lockout as Authenticate.
+	peerAddr := peerHost(ctx)
+	attempt, err := checkLogin(caller.username, peerAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer attempt.release()
+	if _, ok := ValidateUser(caller.username, req.OldPassword); !ok {
+		attempt.fail()
+		log.Printf("Gateway: Password change for %s rejected: old password does not match", caller.username)
+		return nil, payerr.New(pb.ErrorReason_INVALID_CREDENTIALS, "Old password is incorrect").Err()
 	}
-	token := fmt.Sprintf("token-%d", time.Now().UnixNano())
-	AddToken(token, req.Username)
-	log.Printf("Gateway: User %s authenticated successfully, token issued: %s", req.Username, token)
-	return &pb.AuthResponse{Token: token, Message: "Authentication successful"}, nil
+	attempt.succeed()
+	hash, err := password.Hash(req.NewPassword)
+	if err != nil {
+		log.Printf("Gateway: Password change for %s failed: could not hash password: %v", caller.username, err)
+		return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, "Password cannot be used").Err()
+	}
+	user, err := ChangeUserPassword(caller.username, hash)
+	if err != nil {
+		log.Printf("Gateway: Password change for %s failed: %v", caller.username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to update gateway users file").Err()
+	}
+	var accounts []accountRef
+	for _, ref := range AccountsOf(caller.username) {
+		if caller.owns(ref.bank, ref.account) {
+			accounts = append(accounts, ref)
+		}
+	}
+	token, info, err := issueToken(caller.username, user.PasswordVersion, accounts)
+	if err != nil {
+		log.Printf("Gateway: Failed to issue token for %s: %v", caller.username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Password changed, but no token could be issued; authenticate again").Err()
+	}
+	log.Printf("Gateway: Password of user %s changed; earlier tokens invalidated, token %s issued until %s",
+		caller.username, info.id, info.expires.Format(time.RFC3339))
+	return &pb.AuthResponse{Token: token, Message: "Password changed", ExpiresUnixMs: info.expires.UnixMilli()}, nil
 }
 
 func (s *server) TransferMoney(ctx context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
 	log.Printf("Gateway: TransferMoney RPC called for txn: %s", req.TransactionId)
 	if !isGatewayActive() {
-		return &pb.TransferResponse{Success: false, Message: "Gateway is offline"}, nil
+		return nil, errGatewayOffline()
+	}
+	if !beginInFlight(req.TransactionId) {
+		log.Printf("Gateway: Txn %s is already in progress; rejecting concurrent duplicate", req.TransactionId)
+		return nil, payerr.New(pb.ErrorReason_TRANSACTION_IN_PROGRESS,
+			fmt.Sprintf("transaction %s is already in progress", req.TransactionId)).Err()
+	}
+	defer endInFlight(req.TransactionId)
+
+	// A transaction id is consumed once the coordinator decides it; later
+	// requests with the same id get the stored reply instead of a new 2PC run.
+	fingerprint := transferFingerprint(req)
+	if stored, ok := lookupTransfer(req.TransactionId); ok {
+		if stored.fingerprint != fingerprint {
+			log.Printf("Gateway: Txn %s reused for a different transfer; rejecting", req.TransactionId)
+			return nil, errTransactionIDReused(req.TransactionId, "used")
+		}
+		log.Printf("Gateway: Txn %s is a replay; returning stored result", req.TransactionId)
+		return stored.response, stored.err
 	}
-	senderAddr, ok := getBankAddress(req.FromBank)
-	if !ok {
+	if queued, ok := queuedFingerprint(req.TransactionId); ok && queued != fingerprint {
+		log.Printf("Gateway: Txn %s is queued for a different transfer; rejecting", req.TransactionId)
+		return nil, errTransactionIDReused(req.TransactionId, "submitted")
+	}
+	// The PIN comes first, so a wrong PIN does not use up a one-time code.
+	if err := requireTransactionPin(ctx, req); err != nil {
+		return nil, err
+	}
+	if err := requireTransferOTP(ctx, req); err != nil {
+		return nil, err
+	}
+	resp, err := s.runTransfer(ctx, req, "", "")
+	if hasDecision(req.TransactionId) {
+		resp, err = storeTransfer(req.TransactionId, fingerprint, resp, err)
+	}
+	return resp, err
+}
+
+func errTransactionIDReused(txnID, how string) error {
+	return payerr.New(pb.ErrorReason_TRANSACTION_ID_REUSED, fmt.Sprintf(
+		"transaction id %s was already %s for a different transfer (amount or accounts differ)", txnID, how)).Err()
+}
+
+// bankFailure describes a failed call to bankName. Errors the bank did not
+// produce itself, such as a refused connection or an expired deadline, mean
+// the bank could not be reached.
+func bankFailure(bankName string, err error) *payerr.Error {
+	failure := payerr.From(err)
+	if failure.Reason == pb.ErrorReason_ERROR_REASON_UNSPECIFIED && failure.Retryable {
+		failure.Reason = pb.ErrorReason_BANK_UNAVAILABLE
+	}
+	if failure.BankName == "" {
+		failure.BankName = bankName
+	}
+	return failure
+}
+
+// runTransfer drives one transfer through two-phase commit. The logged
+// addresses, if any, are used for banks that have not registered since the
+// gateway restarted. Failures are returned as payerr errors; bank failures
+// keep the reason the bank gave.
+func (s *server) runTransfer(ctx context.Context, req *pb.TransferRequest, loggedFromAddr, loggedToAddr string) (*pb.TransferResponse, error) {
+	senderAddr := resolveBankAddress(req.FromBank, loggedFromAddr)
+	if senderAddr == "" {
 		msg := fmt.Sprintf("Sender bank '%s' is not registered.", req.FromBank)
 		log.Printf("Gateway: %s", msg)
-		return &pb.TransferResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_BANK_NOT_REGISTERED, msg).Err()
 	}
-	receiverAddr, ok := getBankAddress(req.ToBank)
-	if !ok {
+	receiverAddr := resolveBankAddress(req.ToBank, loggedToAddr)
+	if receiverAddr == "" {
 		msg := fmt.Sprintf("Receiver bank '%s' is not registered.", req.ToBank)
 		log.Printf("Gateway: %s", msg)
-		return &pb.TransferResponse{Success: false, Message: msg}, nil
+		return nil, payerr.New(pb.ErrorReason_BANK_NOT_REGISTERED, msg).Err()
 	}
-	senderConn, err := grpc.Dial(senderAddr, grpc.WithInsecure())
+	senderConn, senderClient, err := dialBank(senderAddr)
 	if err != nil {
 		log.Printf("Gateway: Failed to connect to sender bank at %s: %v", senderAddr, err)
-		return &pb.TransferResponse{Success: false, Message: "Failed to connect to sender bank."}, nil
+		failure := payerr.New(pb.ErrorReason_BANK_UNAVAILABLE, "Failed to connect to sender bank.")
+		failure.BankName = req.FromBank
+		return nil, failure.Err()
 	}
 	defer senderConn.Close()
-	senderClient := pb.NewBankClient(senderConn)
-	receiverConn, err := grpc.Dial(receiverAddr, grpc.WithInsecure())
+	receiverConn, receiverClient, err := dialBank(receiverAddr)
 	if err != nil {
 		log.Printf("Gateway: Failed to connect to receiver bank at %s: %v", receiverAddr, err)
-		return &pb.TransferResponse{Success: false, Message: "Failed to connect to receiver bank."}, nil
+		failure := payerr.New(pb.ErrorReason_BANK_UNAVAILABLE, "Failed to connect to receiver bank.")
+		failure.BankName = req.ToBank
+		return nil, failure.Err()
 	}
 	defer receiverConn.Close()
-	receiverClient := pb.NewBankClient(receiverConn)
 
-	// Create composite keys.
-	debitKey := req.TransactionId + "-debit"
-	creditKey := req.TransactionId + "-credit"
+	rec := &coordinatorRecord{
+		txnID:       req.TransactionId,
+		fromBank:    req.FromBank,
+		fromAddr:    senderAddr,
+		fromAccount: req.FromAccount,
+		toBank:      req.ToBank,
+		toAddr:      receiverAddr,
+		toAccount:   req.ToAccount,
+		amount:      req.Amount,
+		deadline:    time.Now().Add(*prepareTimeout),
+	}
+	prepDebitReq := rec.debitRequest()
+	prepCreditReq := rec.creditRequest()
 
-	prepDebitReq := &pb.DebitCreditRequest{
-		AccountId:           req.FromAccount,
-		Amount:              req.Amount,
-		TransactionId:       debitKey,
-		CounterpartyAccount: req.ToAccount,
+	// PREPARE PHASE: the pending entry only makes the transfer visible to
+	// GetTransferStatus; until a decision is logged a crash is resolved by
+	// presumed abort.
+	if err := logCoordinatorState(rec, statePending); err != nil {
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Gateway could not record transaction.").Err()
 	}
-	debitPrepResp, err := senderClient.PrepareDebit(ctx, prepDebitReq)
-	if err != nil || !debitPrepResp.Success {
-		msg := fmt.Sprintf("Debit preparation failed: %v, %s", err, debitPrepResp.Message)
-		log.Printf("Gateway: %s", msg)
-		return &pb.TransferResponse{Success: false, Message: msg}, nil
+	if _, err := senderClient.PrepareDebit(ctx, prepDebitReq); err != nil {
+		// Nothing is decided yet, so the bank's verdict on retrying stands:
+		// the same transaction id may be sent again.
+		failure := bankFailure(req.FromBank, err)
+		failure.Message = fmt.Sprintf("Debit preparation failed at %s: %s", req.FromBank, failure.Message)
+		log.Printf("Gateway: %s (%s)", failure.Message, failure.Reason)
+		logLegEvent(rec, legDebit, legFailed, failure.Message)
+		logCoordinatorEntry(rec, stateFailed, failure.Message)
+		return nil, failure.Err()
 	}
-	log.Printf("Gateway: Debit preparation succeeded for txn %s", debitKey)
+	logLegEvent(rec, legDebit, legPrepared, "")
+	log.Printf("Gateway: Debit preparation succeeded for txn %s", prepDebitReq.TransactionId)
 
-	prepCreditReq := &pb.DebitCreditRequest{
-		AccountId:           req.ToAccount,
-		Amount:              req.Amount,
-		TransactionId:       creditKey,
-		CounterpartyAccount: req.FromAccount,
+	if _, err := receiverClient.PrepareCredit(ctx, prepCreditReq); err != nil {
+		failure := bankFailure(req.ToBank, err)
+		failure.Message = fmt.Sprintf("Credit preparation failed at %s: %s", req.ToBank, failure.Message)
+		log.Printf("Gateway: %s (%s)", failure.Message, failure.Reason)
+		logLegEvent(rec, legCredit, legFailed, failure.Message)
+		if logCoordinatorState(rec, stateAbortDecide) == nil {
+			s.finishTransaction(rec)
+		} else {
+			_, _ = senderClient.AbortDebit(ctx, prepDebitReq)
+		}
+		return nil, failure.Err()
 	}
-	creditPrepResp, err := receiverClient.PrepareCredit(ctx, prepCreditReq)
-	if err != nil || !creditPrepResp.Success {
-		msg := fmt.Sprintf("Credit preparation failed: %v, %s", err, creditPrepResp.Message)
-		log.Printf("Gateway: %s", msg)
+	logLegEvent(rec, legCredit, legPrepared, "")
+	log.Printf("Gateway: Credit preparation succeeded for txn %s", prepCreditReq.TransactionId)
+
+	if err := logCoordinatorState(rec, statePrepared); err != nil {
 		_, _ = senderClient.AbortDebit(ctx, prepDebitReq)
-		return &pb.TransferResponse{Success: false, Message: msg}, nil
-	}
-	log.Printf("Gateway: Credit preparation succeeded for txn %s", creditKey)
-
-	// COMMIT PHASE:
-	commitDebitResp, err := senderClient.CommitDebit(ctx, prepDebitReq)
-	if err != nil || !commitDebitResp.Success {
-		lowerMsg := strings.ToLower(commitDebitResp.Message)
-		if err != nil || strings.Contains(lowerMsg, "offline") || strings.Contains(lowerMsg, "down") {
-			msg := fmt.Sprintf("Debit commit failed due to sender bank down: %v, %s", err, commitDebitResp.Message)
-			log.Printf("Gateway: %s", msg)
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
-		} else if strings.Contains(lowerMsg, "not found") || strings.Contains(lowerMsg, "invalid credentials") {
-			msg := fmt.Sprintf("Debit commit failed due to authentication error: %v, %s", err, commitDebitResp.Message)
-			log.Printf("Gateway: %s", msg)
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
-		} else {
-			msg := fmt.Sprintf("Debit commit failed: %v, %s", err, commitDebitResp.Message)
-			log.Printf("Gateway: %s", msg)
-			_, _ = receiverClient.AbortCredit(ctx, prepCreditReq)
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
+		_, _ = receiverClient.AbortCredit(ctx, prepCreditReq)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Gateway could not record transaction; aborted.").Err()
+	}
+
+	// Banks release holds once the prepare deadline passes, so committing
+	// after it could find a participant that has already presumed abort.
+	if !time.Now().Before(rec.deadline) {
+		msg := "Prepare deadline passed before commit; transaction aborted."
+		log.Printf("Gateway: Txn %s: %s", req.TransactionId, msg)
+		if logCoordinatorState(rec, stateAbortDecide) == nil {
+			s.finishTransaction(rec)
 		}
+		return nil, payerr.New(pb.ErrorReason_TRANSACTION_ABORTED, msg).Err()
 	}
-	log.Printf("Gateway: Debit commit succeeded for txn %s", debitKey)
-
-	commitCreditResp, err := receiverClient.CommitCredit(ctx, prepCreditReq)
-	if err != nil || !commitCreditResp.Success {
-		lowerMsg := strings.ToLower(commitCreditResp.Message)
-		if err != nil || strings.Contains(lowerMsg, "offline") || strings.Contains(lowerMsg, "down") {
-			msg := fmt.Sprintf("Credit commit failed due to receiver bank down: %v, %s", err, commitCreditResp.Message)
-			log.Printf("Gateway: %s", msg)
-			// Attempt to revert debit.
-			abortResp, abortErr := senderClient.AbortDebit(ctx, prepDebitReq)
-			if abortErr != nil || !abortResp.Success {
-				log.Printf("Gateway: Critical: Unable to revert debit after credit failure: %v, %s", abortErr, abortResp.Message)
-			}
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
-		} else if strings.Contains(lowerMsg, "not found") || strings.Contains(lowerMsg, "invalid credentials") {
-			msg := fmt.Sprintf("Credit commit failed due to authentication error: %v, %s", err, commitCreditResp.Message)
-			log.Printf("Gateway: %s", msg)
-			_, _ = senderClient.AbortDebit(ctx, prepDebitReq)
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
-		} else {
-			msg := fmt.Sprintf("Credit commit failed: %v, %s", err, commitCreditResp.Message)
-			log.Printf("Gateway: %s", msg)
-			_, _ = senderClient.AbortDebit(ctx, prepDebitReq)
-			return &pb.TransferResponse{Success: false, Message: msg}, nil
+
+	// COMMIT PHASE: once the decision is on disk it is final and is
+	// delivered until both banks acknowledge, even if the client gives up.
+	if err := logCoordinatorState(rec, stateCommitDecide); err != nil {
+		if logCoordinatorState(rec, stateAbortDecide) == nil {
+			s.finishTransaction(rec)
 		}
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Gateway could not record commit decision; aborted.").Err()
+	}
+	log.Printf("Gateway: Commit decided for txn %s", req.TransactionId)
+	if !s.finishTransaction(rec) {
+		msg := "Transaction committed; delivery to a participant bank is pending and will be retried."
+		log.Printf("Gateway: Txn %s: %s", req.TransactionId, msg)
+		return &pb.TransferResponse{Success: true, Message: msg}, nil
 	}
-	log.Printf("Gateway: Credit commit succeeded for txn %s", creditKey)
 	log.Printf("Gateway: Transaction %s processed successfully", req.TransactionId)
 	return &pb.TransferResponse{Success: true, Message: "Transaction processed successfully"}, nil
 }
 
+// finishTransaction makes one attempt to deliver a logged decision
+// independently of the caller's deadline; the recovery loop retries
+// whatever is left.
+func (s *server) finishTransaction(rec *coordinatorRecord) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return driveDecision(ctx, rec)
+}
+
 func (s *server) CheckBalance(ctx context.Context, req *pb.BalanceRequest) (*pb.BalanceResponse, error) {
 	log.Printf("Gateway: CheckBalance called for account: %s, bank: %s", req.AccountId, req.BankName)
-	bankAddr, ok := getBankAddress(req.BankName)
-	if !ok {
-		msg := fmt.Sprintf("Bank '%s' is not registered.", req.BankName)
-		log.Printf("Gateway: %s", msg)
-		return &pb.BalanceResponse{Balance: 0, Message: msg}, nil
-	}
-	bankConn, err := grpc.Dial(bankAddr, grpc.WithInsecure())
+	bankConn, bankClient, err := connectBank(req.BankName)
 	if err != nil {
-		log.Printf("Gateway: Failed to connect to bank at %s: %v", bankAddr, err)
-		return &pb.BalanceResponse{Balance: 0, Message: "Failed to connect to bank."}, nil
+		return nil, err
 	}
 	defer bankConn.Close()
-	bankClient := pb.NewBankClient(bankConn)
 	balResp, err := bankClient.GetBalance(ctx, req)
 	if err != nil {
-		log.Printf("Gateway: Bank GetBalance error: %v", err)
-		return &pb.BalanceResponse{Balance: 0, Message: "Bank error"}, nil
+		failure := bankFailure(req.BankName, err)
+		log.Printf("Gateway: Bank GetBalance error: %s (%s)", failure.Message, failure.Reason)
+		return nil, failure.Err()
 	}
-	log.Printf("Gateway: Balance for account %s: %.2f", req.AccountId, balResp.Balance)
+	log.Printf("Gateway: Balance for account %s: %s (available %s)", req.AccountId, money.Format(balResp.Balance), money.Format(balResp.AvailableBalance))
 	return balResp, nil
 }
 
 func main() {
 	flag.Parse()
 	LoadGatewayUsers()
+	LoadGatewayAccounts()
+	LoadBankKeys()
+	if err := loadTokenKey(); err != nil {
+		log.Fatalf("Gateway: failed to load token signing key: %v", err)
+	}
+	if err := openRevocationList(); err != nil {
+		log.Fatalf("Gateway: failed to open token revocation list: %v", err)
+	}
+	if err := openCoordinatorLog(); err != nil {
+		log.Fatalf("Gateway: failed to open coordinator log: %v", err)
+	}
+	if err := openIdempotencyStore(); err != nil {
+		log.Fatalf("Gateway: failed to open idempotency store: %v", err)
+	}
+	go recoverUnfinishedTransactions(*recoveryInterval)
+	gateway := &server{}
+	gateway.startTransferWorkers(*transferWorkers)
 
 	cert, err := tls.LoadX509KeyPair("../certs/gateway.pem", "../certs/gateway.key")
 	if err != nil {
@@ -289,9 +577,10 @@ func main() {
 	}
 	grpcServer := grpc.NewServer(
 		grpc.Creds(creds),
-		grpc.UnaryInterceptor(AuthInterceptor),
+		grpc.ChainUnaryInterceptor(AuthInterceptor, validate.UnaryServerInterceptor(isBankRegistered)),
+		grpc.StreamInterceptor(AuthStreamInterceptor),
 	)
-	pb.RegisterPaymentGatewayServer(grpcServer, &server{})
+	pb.RegisterPaymentGatewayServer(grpcServer, gateway)
 	log.Printf("Gateway: Server listening on port %d", *port)
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("Gateway: failed to serve: %s", err)
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"payment_gateway/password"
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+)
+
+var (
+	maxFailedPins      = flag.Int("max-failed-pins", 3, "Wrong transaction PINs for one user before their transfers are locked out")
+	pinLockoutDuration = flag.Duration("pin-lockout-duration", 30*time.Minute, "How long a PIN lockout lasts unless lifted with 'unlock'")
+)
+
+// pinGuard counts wrong transaction PINs by username. It is kept apart from
+// loginGuard: a PIN has few digits, so it gets fewer tries, and logging in
+// again does not clear it. Failures older than -login-failure-window are
+// forgotten.
+var pinGuard = struct {
+	sync.Mutex
+	users map[string]*loginAttempts
+}{users: make(map[string]*loginAttempts)}
+
+// checkPinLockout rejects transfers of username while its PIN is locked out.
+func checkPinLockout(username string) error {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	return pinLockoutLocked(attemptsLocked(pinGuard.users, username, time.Now(), false))
+}
+
+func pinLockoutLocked(a *loginAttempts) error {
+	now := time.Now()
+	if now.Before(a.lockedUntil) {
+		failure := payerr.New(pb.ErrorReason_PIN_LOCKED_OUT,
+			fmt.Sprintf("Too many wrong PINs; transfers are locked until %s", a.lockedUntil.Format(time.RFC3339)))
+		failure.RetryDelay = a.lockedUntil.Sub(now)
+		return failure.Err()
+	}
+	return nil
+}
+
+// reservePinAttempt admits a PIN check for username unless its PIN is
+// locked out or as many checks are already in progress as it has tries
+// left. The check must end with recordPinFailure or recordPinSuccess.
+func reservePinAttempt(username string) error {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	a := attemptsLocked(pinGuard.users, username, time.Now(), true)
+	if err := pinLockoutLocked(a); err != nil {
+		return err
+	}
+	if a.failures+a.reserved >= *maxFailedPins {
+		failure := payerr.New(pb.ErrorReason_PIN_LOCKED_OUT, "Too many PINs are being checked at once; try again shortly")
+		failure.RetryDelay = *loginDelayBase
+		return failure.Err()
+	}
+	a.reserved++
+	return nil
+}
+
+// recordPinFailure ends a PIN check with a wrong PIN, locks out username
+// once it reaches -max-failed-pins and returns how many tries are left.
+func recordPinFailure(username, peerAddr string) int {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	unreserveLocked(pinGuard.users, username)
+	now := time.Now()
+	a := attemptsLocked(pinGuard.users, username, now, true)
+	a.failures++
+	a.lastFailure = now
+	if a.failures >= *maxFailedPins && a.lockedUntil.IsZero() {
+		a.lockedUntil = now.Add(*pinLockoutDuration)
+		audit("pin_lockout", username, peerAddr, fmt.Sprintf("transfers locked for %s after %d wrong PINs", *pinLockoutDuration, a.failures))
+	}
+	return *maxFailedPins - a.failures
+}
+
+// recordPinSuccess ends a PIN check with the right PIN and clears the
+// failures of username.
+func recordPinSuccess(username string) {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	unreserveLocked(pinGuard.users, username)
+	clearFailuresLocked(pinGuard.users, username)
+}
+
+func clearPinFailures(username string) {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	clearFailuresLocked(pinGuard.users, username)
+}
+
+// unlockPin lifts the PIN lockout of username and reports whether there was
+// anything to clear.
+func unlockPin(username string) bool {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	if _, ok := pinGuard.users[username]; !ok {
+		return false
+	}
+	delete(pinGuard.users, username)
+	audit("unlock", username, "", "PIN failures cleared by administrator")
+	return true
+}
+
+// lockedPins lists current PIN lockouts, for the 'locks' command.
+func lockedPins() []string {
+	pinGuard.Lock()
+	defer pinGuard.Unlock()
+	now := time.Now()
+	var locks []string
+	for username, a := range pinGuard.users {
+		if now.Before(a.lockedUntil) {
+			locks = append(locks, fmt.Sprintf("PIN of user %s locked until %s (%d failures)", username, a.lockedUntil.Format(time.RFC3339), a.failures))
+		}
+	}
+	sort.Strings(locks)
+	return locks
+}
+
+// requireTransactionPin checks the PIN of a transfer from a user who has
+// set one. Users without a PIN are not asked and any PIN they send is
+// ignored.
+func requireTransactionPin(ctx context.Context, req *pb.TransferRequest) error {
+	caller := callerFrom(ctx)
+	user, _ := GetUser(caller.username)
+	if user.TransactionPIN == "" {
+		return nil
+	}
+	if err := checkPinLockout(caller.username); err != nil {
+		return err
+	}
+	if req.Pin == "" {
+		return payerr.New(pb.ErrorReason_PIN_REQUIRED, "A transaction PIN is required").Err()
+	}
+	// The attempt is reserved before the slow hash check, so parallel
+	// transfers cannot try more PINs than the user has tries left.
+	if err := reservePinAttempt(caller.username); err != nil {
+		return err
+	}
+	if !password.Check(user.TransactionPIN, req.Pin) {
+		left := recordPinFailure(caller.username, peerHost(ctx))
+		log.Printf("Gateway: Txn %s rejected: wrong PIN for user %s", req.TransactionId, caller.username)
+		if left <= 0 {
+			return checkPinLockout(caller.username)
+		}
+		return payerr.New(pb.ErrorReason_INVALID_PIN, fmt.Sprintf("Transaction PIN is incorrect; %d attempt(s) left", left)).Err()
+	}
+	recordPinSuccess(caller.username)
+	return nil
+}
+
+// SetTransactionPin sets, replaces or, given an empty PIN, removes the
+// caller's transaction PIN once their password is confirmed. The password
+// counts towards the login lockout, as in ChangePassword.
+func (s *server) SetTransactionPin(ctx context.Context, req *pb.SetTransactionPinRequest) (*pb.SetTransactionPinResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: SetTransactionPin called for user %s", caller.username)
+	peerAddr := peerHost(ctx)
+	attempt, err := checkLogin(caller.username, peerAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer attempt.release()
+	if _, ok := ValidateUser(caller.username, req.Password); !ok {
+		attempt.fail()
+		log.Printf("Gateway: PIN change for %s rejected: password does not match", caller.username)
+		return nil, payerr.New(pb.ErrorReason_INVALID_CREDENTIALS, "Password is incorrect").Err()
+	}
+	attempt.succeed()
+	hash := ""
+	if req.NewPin != "" {
+		if hash, err = password.Hash(req.NewPin); err != nil {
+			log.Printf("Gateway: PIN change for %s failed: could not hash PIN: %v", caller.username, err)
+			return nil, payerr.New(pb.ErrorReason_INVALID_REQUEST, "PIN cannot be used").Err()
+		}
+	}
+	_, err = UpdateUser(caller.username, func(u *User) error {
+		u.TransactionPIN = hash
+		return nil
+	})
+	if err != nil {
+		log.Printf("Gateway: Could not save transaction PIN of %s: %v", caller.username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to update gateway users file").Err()
+	}
+	clearPinFailures(caller.username)
+	if hash == "" {
+		audit("pin_removed", caller.username, peerAddr, "")
+		return &pb.SetTransactionPinResponse{Message: "Transaction PIN removed"}, nil
+	}
+	audit("pin_set", caller.username, peerAddr, "")
+	return &pb.SetTransactionPinResponse{Message: "Transaction PIN set"}, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+)
+
+// GetTransferStatus reports the coordinator's view of a transfer: the
+// overall state, the logged decision and the progress of each leg.
+func (s *server) GetTransferStatus(ctx context.Context, req *pb.TransferStatusRequest) (*pb.TransferStatusResponse, error) {
+	log.Printf("Gateway: GetTransferStatus called for txn: %s", req.TransactionId)
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	rec, ok := coordinatorLog.records[req.TransactionId]
+	if !ok {
+		return nil, errTransferNotFound(req.TransactionId)
+	}
+	if err := requireParticipant(callerFrom(ctx), rec); err != nil {
+		return nil, err
+	}
+	return transferStatusLocked(rec), nil
+}
+
+// requireParticipant lets the owners of either account of a transfer see
+// its status.
+func requireParticipant(caller tokenInfo, rec *coordinatorRecord) error {
+	if caller.owns(rec.fromBank, rec.fromAccount) || caller.owns(rec.toBank, rec.toAccount) {
+		return nil
+	}
+	return payerr.New(pb.ErrorReason_ACCOUNT_NOT_OWNED,
+		fmt.Sprintf("transfer %s does not involve an account of user %s", rec.txnID, caller.username)).Err()
+}
+
+func errTransferNotFound(txnID string) error {
+	return payerr.New(pb.ErrorReason_TRANSFER_NOT_FOUND, fmt.Sprintf("no transfer with id %s is known to the gateway", txnID)).Err()
+}
+
+// transferStatusLocked builds the status reply for rec. The caller must
+// hold coordinatorLog.
+func transferStatusLocked(rec *coordinatorRecord) *pb.TransferStatusResponse {
+	resp := &pb.TransferStatusResponse{
+		TransactionId: rec.txnID,
+		Decision:      pb.TransactionDecision_DECISION_PENDING,
+		Amount:        rec.amount,
+		CreatedUnixMs: unixMillis(rec.created),
+		UpdatedUnixMs: unixMillis(rec.updated),
+		Legs: []*pb.LegStatus{
+			legStatus(pb.LegType_LEG_DEBIT, rec.fromBank, rec.fromAccount, rec.debit),
+			legStatus(pb.LegType_LEG_CREDIT, rec.toBank, rec.toAccount, rec.credit),
+		},
+	}
+	switch rec.decision {
+	case stateCommitDecide:
+		resp.Decision = pb.TransactionDecision_DECISION_COMMIT
+	case stateAbortDecide:
+		resp.Decision = pb.TransactionDecision_DECISION_ABORT
+	}
+
+	switch {
+	case rec.state == stateDone && rec.decision == stateCommitDecide:
+		resp.State = pb.TransferState_TRANSFER_COMMITTED
+		resp.Message = "Transfer committed at both banks."
+	case rec.state == stateDone:
+		resp.State = pb.TransferState_TRANSFER_ABORTED
+		resp.Message = "Transfer aborted; no funds moved."
+	case rec.state == stateFailed:
+		resp.State = pb.TransferState_TRANSFER_ABORTED
+		resp.Decision = pb.TransactionDecision_DECISION_ABORT
+		resp.Message = "Transfer failed before the sender bank prepared; no funds moved."
+		if rec.detail != "" {
+			resp.Message = rec.detail
+		}
+	case rec.state == stateQueued:
+		resp.State = pb.TransferState_TRANSFER_PENDING
+		resp.Message = "Queued for processing."
+	case rec.decision != "":
+		resp.State = pb.TransferState_TRANSFER_IN_DOUBT
+		resp.Message = fmt.Sprintf("Decision to %s logged; waiting for %s to acknowledge.", strings.TrimSuffix(rec.decision, "-decided"), unackedBanks(rec))
+	case isInFlight(rec.txnID) && rec.state == statePrepared:
+		resp.State = pb.TransferState_TRANSFER_PREPARED
+		resp.Message = "Both banks prepared; decision in progress."
+	case isInFlight(rec.txnID):
+		resp.State = pb.TransferState_TRANSFER_PENDING
+		resp.Message = "Waiting for the banks to prepare."
+	default:
+		// Only possible between a crash and the presumed-abort pass at
+		// startup; kept for completeness.
+		resp.State = pb.TransferState_TRANSFER_IN_DOUBT
+		resp.Message = "Coordinator stopped before deciding; the transfer will be aborted."
+	}
+	return resp
+}
+
+func legStatus(leg pb.LegType, bank, account string, progress legProgress) *pb.LegStatus {
+	return &pb.LegStatus{
+		Leg:           leg,
+		BankName:      bank,
+		AccountId:     account,
+		State:         progress.state,
+		Message:       progress.detail,
+		UpdatedUnixMs: unixMillis(progress.updated),
+	}
+}
+
+func unackedBanks(rec *coordinatorRecord) string {
+	switch {
+	case !rec.debit.acked && !rec.credit.acked:
+		return rec.fromBank + " and " + rec.toBank
+	case !rec.debit.acked:
+		return rec.fromBank
+	default:
+		return rec.toBank
+	}
+}
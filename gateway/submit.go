@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+)
+
+var transferWorkers = flag.Int("transfer-workers", 4, "Number of workers running transfers accepted by SubmitTransfer")
+
+// watchPollInterval bounds how long WatchTransfer goes without re-reading
+// the status, for changes that are not logged (e.g. a run ending).
+const watchPollInterval = 2 * time.Second
+
+// submissionQueue holds the ids of queued transfers waiting for a worker.
+// The coordinator log is the durable copy; this is rebuilt from it at
+// startup.
+var submissionQueue = struct {
+	sync.Mutex
+	ids   []string
+	ready chan struct{} // signalled while ids is non-empty
+}{ready: make(chan struct{}, 1)}
+
+func enqueueSubmission(txnID string) {
+	submissionQueue.Lock()
+	submissionQueue.ids = append(submissionQueue.ids, txnID)
+	submissionQueue.Unlock()
+	select {
+	case submissionQueue.ready <- struct{}{}:
+	default:
+	}
+}
+
+func nextSubmission() string {
+	for {
+		submissionQueue.Lock()
+		if len(submissionQueue.ids) > 0 {
+			txnID := submissionQueue.ids[0]
+			submissionQueue.ids = submissionQueue.ids[1:]
+			more := len(submissionQueue.ids) > 0
+			submissionQueue.Unlock()
+			if more {
+				select {
+				case submissionQueue.ready <- struct{}{}:
+				default:
+				}
+			}
+			return txnID
+		}
+		submissionQueue.Unlock()
+		<-submissionQueue.ready
+	}
+}
+
+// startTransferWorkers re-queues transfers that were accepted but not
+// started before the last shutdown and starts the worker pool.
+func (s *server) startTransferWorkers(n int) {
+	coordinatorLog.Lock()
+	var queued []*coordinatorRecord
+	for _, rec := range coordinatorLog.records {
+		if rec.state == stateQueued {
+			queued = append(queued, rec)
+		}
+	}
+	coordinatorLog.Unlock()
+	sort.Slice(queued, func(i, j int) bool { return queued[i].created.Before(queued[j].created) })
+	for _, rec := range queued {
+		enqueueSubmission(rec.txnID)
+	}
+	if len(queued) > 0 {
+		log.Printf("Gateway: Re-queued %d submitted transfer(s)", len(queued))
+	}
+	for i := 0; i < n; i++ {
+		go func() {
+			for {
+				s.processSubmission(nextSubmission())
+			}
+		}()
+	}
+}
+
+// processSubmission runs a queued transfer. Transfers already started by a
+// TransferMoney call with the same id are left to that call.
+func (s *server) processSubmission(txnID string) {
+	if !beginInFlight(txnID) {
+		return
+	}
+	defer endInFlight(txnID)
+	coordinatorLog.Lock()
+	rec, ok := coordinatorLog.records[txnID]
+	var req *pb.TransferRequest
+	var fromAddr, toAddr string
+	if ok && rec.state == stateQueued {
+		req = rec.transferRequest()
+		fromAddr, toAddr = rec.fromAddr, rec.toAddr
+	}
+	coordinatorLog.Unlock()
+	if req == nil {
+		return
+	}
+	log.Printf("Gateway: Worker starting submitted txn %s", txnID)
+	ctx, cancel := context.WithTimeout(context.Background(), *prepareTimeout)
+	resp, err := s.runTransfer(ctx, req, fromAddr, toAddr)
+	cancel()
+	outcome := resp.GetMessage()
+	if err != nil {
+		failure := payerr.From(err)
+		outcome = fmt.Sprintf("%s (%s)", failure.Message, failure.Reason)
+	}
+	if hasDecision(txnID) {
+		storeTransfer(txnID, transferFingerprint(req), resp, err)
+	} else if stillQueued(txnID) {
+		// runTransfer gave up before reaching the banks; record why so
+		// watchers see a final state.
+		logCoordinatorEntry(rec, stateFailed, status.Convert(err).Message())
+	}
+	log.Printf("Gateway: Worker finished submitted txn %s: %s", txnID, outcome)
+}
+
+// queuedFingerprint reports whether txnID is accepted but not yet started,
+// and returns the fingerprint of the queued transfer.
+func queuedFingerprint(txnID string) (string, bool) {
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	rec, ok := coordinatorLog.records[txnID]
+	if !ok || rec.state != stateQueued {
+		return "", false
+	}
+	return fingerprintOf(rec.fromBank, rec.fromAccount, rec.toBank, rec.toAccount, rec.amount), true
+}
+
+func stillQueued(txnID string) bool {
+	_, ok := queuedFingerprint(txnID)
+	return ok
+}
+
+func (rec *coordinatorRecord) transferRequest() *pb.TransferRequest {
+	return &pb.TransferRequest{
+		TransactionId: rec.txnID,
+		FromBank:      rec.fromBank,
+		FromAccount:   rec.fromAccount,
+		ToBank:        rec.toBank,
+		ToAccount:     rec.toAccount,
+		Amount:        rec.amount,
+	}
+}
+
+// SubmitTransfer queues a transfer and returns once it is on disk. A
+// repeated submission of the same transfer is accepted again without
+// queuing it twice; a failed transfer may be resubmitted under its id.
+func (s *server) SubmitTransfer(ctx context.Context, req *pb.TransferRequest) (*pb.SubmitTransferResponse, error) {
+	log.Printf("Gateway: SubmitTransfer RPC called for txn: %s", req.TransactionId)
+	fromAddr, ok := getBankAddress(req.FromBank)
+	if !ok {
+		return nil, payerr.New(pb.ErrorReason_BANK_NOT_REGISTERED, fmt.Sprintf("sender bank '%s' is not registered", req.FromBank)).Err()
+	}
+	toAddr, ok := getBankAddress(req.ToBank)
+	if !ok {
+		return nil, payerr.New(pb.ErrorReason_BANK_NOT_REGISTERED, fmt.Sprintf("receiver bank '%s' is not registered", req.ToBank)).Err()
+	}
+	fingerprint := transferFingerprint(req)
+	// Checking a PIN or recovery code is slow, so it happens outside the log
+	// lock. Resubmissions of a known transfer need neither.
+	coordinatorLog.Lock()
+	existing, known := coordinatorLog.records[req.TransactionId]
+	resubmission := known && existing.state != stateFailed
+	coordinatorLog.Unlock()
+	if !resubmission {
+		if err := requireTransactionPin(ctx, req); err != nil {
+			return nil, err
+		}
+		if err := requireTransferOTP(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	coordinatorLog.Lock()
+	defer coordinatorLog.Unlock()
+	if rec, ok := coordinatorLog.records[req.TransactionId]; ok && rec.state != stateFailed {
+		if fingerprintOf(rec.fromBank, rec.fromAccount, rec.toBank, rec.toAccount, rec.amount) != fingerprint {
+			log.Printf("Gateway: Txn %s reused for a different transfer; rejecting submission", req.TransactionId)
+			return nil, errTransactionIDReused(req.TransactionId, "used")
+		}
+		current := transferStatusLocked(rec)
+		return &pb.SubmitTransferResponse{
+			TransactionId: req.TransactionId,
+			Accepted:      true,
+			State:         current.State,
+			Message:       "Transfer was already submitted: " + current.Message,
+		}, nil
+	}
+	rec := &coordinatorRecord{
+		txnID:       req.TransactionId,
+		fromBank:    req.FromBank,
+		fromAddr:    fromAddr,
+		fromAccount: req.FromAccount,
+		toBank:      req.ToBank,
+		toAddr:      toAddr,
+		toAccount:   req.ToAccount,
+		amount:      req.Amount,
+	}
+	if err := appendCoordinatorEntryLocked(rec, stateQueued, ""); err != nil {
+		log.Printf("Gateway: Failed to queue txn %s: %v", req.TransactionId, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "gateway could not record the transfer").Err()
+	}
+	enqueueSubmission(req.TransactionId)
+	log.Printf("Gateway: Txn %s queued", req.TransactionId)
+	return &pb.SubmitTransferResponse{
+		TransactionId: req.TransactionId,
+		Accepted:      true,
+		State:         pb.TransferState_TRANSFER_PENDING,
+		Message:       "Transfer queued for processing.",
+	}, nil
+}
+
+// transferWatchers wakes WatchTransfer streams when a transfer's log entry
+// is written.
+var transferWatchers = struct {
+	sync.Mutex
+	chans map[string]map[chan struct{}]bool
+}{chans: make(map[string]map[chan struct{}]bool)}
+
+func addTransferWatcher(txnID string) chan struct{} {
+	ch := make(chan struct{}, 1)
+	transferWatchers.Lock()
+	defer transferWatchers.Unlock()
+	if transferWatchers.chans[txnID] == nil {
+		transferWatchers.chans[txnID] = make(map[chan struct{}]bool)
+	}
+	transferWatchers.chans[txnID][ch] = true
+	return ch
+}
+
+func removeTransferWatcher(txnID string, ch chan struct{}) {
+	transferWatchers.Lock()
+	defer transferWatchers.Unlock()
+	delete(transferWatchers.chans[txnID], ch)
+	if len(transferWatchers.chans[txnID]) == 0 {
+		delete(transferWatchers.chans, txnID)
+	}
+}
+
+func notifyTransferWatchers(txnID string) {
+	transferWatchers.Lock()
+	defer transferWatchers.Unlock()
+	for ch := range transferWatchers.chans[txnID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// WatchTransfer sends the current status of a transfer and then every
+// change to it, and ends once the transfer is committed or aborted.
+func (s *server) WatchTransfer(req *pb.TransferStatusRequest, stream pb.PaymentGateway_WatchTransferServer) error {
+	log.Printf("Gateway: WatchTransfer called for txn: %s", req.TransactionId)
+	changed := addTransferWatcher(req.TransactionId)
+	defer removeTransferWatcher(req.TransactionId, changed)
+	var last *pb.TransferStatusResponse
+	for {
+		coordinatorLog.Lock()
+		rec, ok := coordinatorLog.records[req.TransactionId]
+		var current *pb.TransferStatusResponse
+		var err error
+		if ok {
+			err = requireParticipant(callerFrom(stream.Context()), rec)
+			current = transferStatusLocked(rec)
+		}
+		coordinatorLog.Unlock()
+		if !ok {
+			return errTransferNotFound(req.TransactionId)
+		}
+		if err != nil {
+			return err
+		}
+		if last == nil || !proto.Equal(last, current) {
+			if err := stream.Send(current); err != nil {
+				return err
+			}
+			last = current
+		}
+		if current.State == pb.TransferState_TRANSFER_COMMITTED || current.State == pb.TransferState_TRANSFER_ABORTED {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-time.After(watchPollInterval):
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
+)
+
+var (
+	tokenKeyFile  = flag.String("token-key", "../certs/token.key", "File holding the hex HMAC key that signs session tokens; created if missing")
+	tokenTTL      = flag.Duration("token-ttl", 15*time.Minute, "How long a session token is accepted")
+	refreshWindow = flag.Duration("token-refresh-window", time.Hour, "How long after expiry a token may still be refreshed")
+)
+
+const revocationFile = "../gateway_revoked_tokens.txt"
+
+// tokenKey signs and verifies session tokens. It is loaded once at startup.
+var tokenKey []byte
+
+// tokenClaims is the signed payload of a session token. A token is
+// base64url(JSON claims) "." base64url(HMAC-SHA256 of the first part).
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Accounts  []string `json:"accounts"` // "<bank>/<account>"
+	IssuedAt  int64    `json:"iat"`      // Unix seconds
+	ExpiresAt int64    `json:"exp"`      // Unix seconds
+	ID        string   `json:"jti"`
+	Version   int      `json:"ver"` // password version of the subject
+}
+
+// loadTokenKey reads the signing key, generating one on first start.
+func loadTokenKey() error {
+	data, err := safefile.ReadFile(*tokenKeyFile)
+	if os.IsNotExist(err) {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		if err := safefile.WriteFile(*tokenKeyFile, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+			return err
+		}
+		log.Printf("Gateway: Generated new token signing key in %s", *tokenKeyFile)
+		tokenKey = key
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fmt.Errorf("%s is not hex: %v", *tokenKeyFile, err)
+	}
+	if len(key) < 32 {
+		return fmt.Errorf("%s holds %d bytes; at least 32 are required", *tokenKeyFile, len(key))
+	}
+	tokenKey = key
+	return nil
+}
+
+func signTokenPayload(payload string) string {
+	mac := hmac.New(sha256.New, tokenKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueToken signs a new token for username covering accounts. version is
+// the user's current password version.
+func issueToken(username string, version int, accounts []accountRef) (string, tokenInfo, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", tokenInfo{}, err
+	}
+	now := time.Now()
+	claims := tokenClaims{
+		Subject:   username,
+		Accounts:  make([]string, len(accounts)),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(*tokenTTL).Unix(),
+		ID:        hex.EncodeToString(id),
+		Version:   version,
+	}
+	for i, ref := range accounts {
+		claims.Accounts[i] = ref.bank + "/" + ref.account
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", tokenInfo{}, err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + signTokenPayload(payload), claims.info(), nil
+}
+
+func (c tokenClaims) info() tokenInfo {
+	info := tokenInfo{
+		username: c.Subject,
+		id:       c.ID,
+		expires:  time.Unix(c.ExpiresAt, 0),
+		version:  c.Version,
+	}
+	for _, a := range c.Accounts {
+		if bank, account, ok := strings.Cut(a, "/"); ok {
+			info.accounts = append(info.accounts, accountRef{bank: bank, account: account})
+		}
+	}
+	return info
+}
+
+// parseToken checks the signature of token and returns its claims. Expiry,
+// revocation and password changes are left to the caller.
+func parseToken(token string) (tokenInfo, error) {
+	invalid := payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Invalid token").Err()
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(signTokenPayload(payload))) {
+		return tokenInfo{}, invalid
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return tokenInfo{}, invalid
+	}
+	var claims tokenClaims
+	if err := json.Unmarshal(body, &claims); err != nil || claims.Subject == "" || claims.ID == "" {
+		return tokenInfo{}, invalid
+	}
+	return claims.info(), nil
+}
+
+// verifyToken returns what token was issued for if it is signed by this
+// gateway, unexpired, not revoked and issued since the user's last password
+// change.
+func verifyToken(token string) (tokenInfo, error) {
+	info, err := parseToken(token)
+	if err != nil {
+		return tokenInfo{}, err
+	}
+	if !time.Now().Before(info.expires) {
+		return tokenInfo{}, payerr.New(pb.ErrorReason_TOKEN_EXPIRED, "Token expired").Err()
+	}
+	if isTokenRevoked(info.id) {
+		return tokenInfo{}, payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Token has been revoked").Err()
+	}
+	if err := checkTokenVersion(info); err != nil {
+		return tokenInfo{}, err
+	}
+	return info, nil
+}
+
+// checkTokenVersion rejects tokens issued before the user's password last
+// changed, or for a user the gateway no longer knows.
+func checkTokenVersion(info tokenInfo) error {
+	version, ok := PasswordVersion(info.username)
+	if !ok || version != info.version {
+		return payerr.New(pb.ErrorReason_UNAUTHENTICATED, "Token was issued before the password was changed; authenticate again").Err()
+	}
+	return nil
+}
+
+// revocationList holds the ids of revoked tokens until they could no longer
+// be refreshed anyway.
+var revocationList = struct {
+	sync.Mutex
+	file *os.File
+	ids  map[string]time.Time // token id -> expiry
+}{ids: make(map[string]time.Time)}
+
+// openRevocationList loads revoked token ids, drops those past the refresh
+// window and keeps the file open for appending.
+func openRevocationList() error {
+	revocationList.Lock()
+	defer revocationList.Unlock()
+	f, err := os.OpenFile(revocationFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	dropped := 0
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Printf("Gateway: Stopped reading revocation list at malformed entry: %v", err)
+			break
+		}
+		if len(fields) != 2 {
+			continue
+		}
+		ms, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if expires := time.UnixMilli(ms); time.Since(expires) < *refreshWindow {
+			revocationList.ids[fields[0]] = expires
+		} else {
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		// Replace the file with one without the entries that no longer
+		// matter, and append to the new file from here on.
+		var buf bytes.Buffer
+		writer := csv.NewWriter(&buf)
+		for id, expires := range revocationList.ids {
+			writer.Write([]string{id, strconv.FormatInt(expires.UnixMilli(), 10)})
+		}
+		writer.Flush()
+		f.Close()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+		if err := safefile.Replace(revocationFile, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+		if f, err = os.OpenFile(revocationFile, os.O_RDWR, 0644); err != nil {
+			return err
+		}
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	revocationList.file = f
+	log.Printf("Gateway: Loaded %d revoked token(s)", len(revocationList.ids))
+	return nil
+}
+
+// errAlreadyRevoked is returned by revokeToken for a token that is already
+// on the revocation list.
+var errAlreadyRevoked = errors.New("token already revoked")
+
+// revokeToken adds a token to the revocation list. Checking and adding
+// happen under one lock, so of two calls racing to revoke the same token
+// exactly one succeeds and the other gets errAlreadyRevoked.
+func revokeToken(info tokenInfo) error {
+	revocationList.Lock()
+	defer revocationList.Unlock()
+	if _, ok := revocationList.ids[info.id]; ok {
+		return errAlreadyRevoked
+	}
+	writer := csv.NewWriter(revocationList.file)
+	err := writer.Write([]string{info.id, strconv.FormatInt(info.expires.UnixMilli(), 10)})
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err == nil {
+		err = revocationList.file.Sync()
+	}
+	if err != nil {
+		return err
+	}
+	revocationList.ids[info.id] = info.expires
+	return nil
+}
+
+func isTokenRevoked(id string) bool {
+	revocationList.Lock()
+	defer revocationList.Unlock()
+	_, ok := revocationList.ids[id]
+	return ok
+}
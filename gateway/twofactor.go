@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"payment_gateway/money"
+	"payment_gateway/password"
+	"payment_gateway/payerr"
+	pb "payment_gateway/proto"
+	"payment_gateway/totp"
+)
+
+var otpTransferThreshold = flag.String("otp-transfer-threshold", "10000.00",
+	"Transfers of at least this amount, in the transfer's currency, need a one-time code; empty turns the check off")
+
+const (
+	totpIssuer = "Payment Gateway"
+	// recoveryCodeCount is how many recovery codes an enrollment hands out.
+	recoveryCodeCount = 10
+	// enrollmentTTL is how long an unconfirmed enrollment waits for
+	// ConfirmTotp.
+	enrollmentTTL = 10 * time.Minute
+)
+
+// pendingEnrollment is a secret and recovery codes waiting for ConfirmTotp.
+type pendingEnrollment struct {
+	secret        string
+	recoveryCodes []string // hashes
+	expires       time.Time
+}
+
+var pendingEnrollments = struct {
+	sync.Mutex
+	byUser map[string]pendingEnrollment
+}{byUser: make(map[string]pendingEnrollment)}
+
+// usedSteps remembers the last TOTP step each user authenticated with, so
+// that a code cannot be used twice. It is not persisted; after a restart a
+// code seen just before it could be replayed within its validity window.
+var usedSteps = struct {
+	sync.Mutex
+	last map[string]int64
+}{last: make(map[string]int64)}
+
+// newRecoveryCode returns a random code of the form xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	raw := make([]byte, 7)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(raw))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// isTOTPCode reports whether code has the shape of a TOTP code rather than a
+// recovery code, so that recovery codes are only hashed when they could
+// match.
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSecondFactor verifies code for username, who must have enrolled. A
+// TOTP code is accepted once; a recovery code is used up.
+func checkSecondFactor(username, peerAddr, code string) error {
+	user, ok := GetUser(username)
+	if !ok || user.TOTPSecret == "" {
+		return payerr.New(pb.ErrorReason_TOTP_NOT_ENROLLED, "Two-factor authentication is not set up; enroll with EnrollTotp").Err()
+	}
+	if code == "" {
+		return payerr.New(pb.ErrorReason_OTP_REQUIRED, "A one-time code is required").Err()
+	}
+	invalid := payerr.New(pb.ErrorReason_INVALID_OTP, "One-time code is invalid or already used").Err()
+	if isTOTPCode(code) {
+		step, ok := totp.Verify(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return invalid
+		}
+		usedSteps.Lock()
+		defer usedSteps.Unlock()
+		if last, seen := usedSteps.last[username]; seen && step <= last {
+			return invalid
+		}
+		usedSteps.last[username] = step
+		return nil
+	}
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	_, err := UpdateUser(username, func(u *User) error {
+		for i, hash := range u.RecoveryCodes {
+			if password.Check(hash, normalized) {
+				u.RecoveryCodes = append(u.RecoveryCodes[:i], u.RecoveryCodes[i+1:]...)
+				return nil
+			}
+		}
+		return invalid
+	})
+	if err == invalid {
+		return invalid
+	}
+	if err != nil {
+		log.Printf("Gateway: Could not use up recovery code of %s: %v", username, err)
+		return payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to update gateway users file").Err()
+	}
+	user, _ = GetUser(username)
+	audit("recovery_code_used", username, peerAddr, fmt.Sprintf("%d recovery code(s) left", len(user.RecoveryCodes)))
+	return nil
+}
+
+// checkLoginSecondFactor asks for a one-time code from users who have
+// enrolled. Wrong codes fail the login attempt.
+func checkLoginSecondFactor(attempt *loginAttempt, code string) error {
+	if user, _ := GetUser(attempt.username); user.TOTPSecret == "" {
+		return nil
+	}
+	err := checkSecondFactor(attempt.username, attempt.peerAddr, code)
+	if payerr.Reason(err) == pb.ErrorReason_INVALID_OTP {
+		attempt.fail()
+	}
+	return err
+}
+
+// needsTransferOTP reports whether amount reaches -otp-transfer-threshold.
+func needsTransferOTP(amount *pb.Money) bool {
+	if *otpTransferThreshold == "" {
+		return false
+	}
+	threshold, err := money.ParseDecimal(*otpTransferThreshold, amount.CurrencyCode)
+	if err != nil {
+		// The threshold cannot be expressed in this currency; err on the
+		// side of asking.
+		return true
+	}
+	cmp, err := money.Cmp(amount, threshold)
+	return err != nil || cmp >= 0
+}
+
+// requireTransferOTP checks the one-time code of a transfer at or above the
+// threshold. Guessing is slowed by the login lockout.
+func requireTransferOTP(ctx context.Context, req *pb.TransferRequest) error {
+	if !needsTransferOTP(req.Amount) {
+		return nil
+	}
+	caller := callerFrom(ctx)
+	peerAddr := peerHost(ctx)
+	attempt, err := checkLogin(caller.username, peerAddr)
+	if err != nil {
+		return err
+	}
+	defer attempt.release()
+	err = checkSecondFactor(caller.username, peerAddr, req.OtpCode)
+	if payerr.Reason(err) == pb.ErrorReason_INVALID_OTP {
+		attempt.fail()
+	}
+	if err != nil {
+		log.Printf("Gateway: Txn %s of %s needs a one-time code: %s", req.TransactionId, money.Format(req.Amount), payerr.From(err).Message)
+	}
+	return err
+}
+
+// EnrollTotp creates a secret and recovery codes for the caller. They
+// replace any existing ones only once ConfirmTotp sees a code for the new
+// secret; replacing an active secret needs a current code for it.
+func (s *server) EnrollTotp(ctx context.Context, req *pb.EnrollTotpRequest) (*pb.EnrollTotpResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: EnrollTotp called for user %s", caller.username)
+	if user, _ := GetUser(caller.username); user.TOTPSecret != "" {
+		peerAddr := peerHost(ctx)
+		attempt, err := checkLogin(caller.username, peerAddr)
+		if err != nil {
+			return nil, err
+		}
+		defer attempt.release()
+		if err := checkLoginSecondFactor(attempt, req.OtpCode); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to generate a secret").Err()
+	}
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		if codes[i], err = newRecoveryCode(); err == nil {
+			hashes[i], err = password.Hash(codes[i])
+		}
+		if err != nil {
+			return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to generate recovery codes").Err()
+		}
+	}
+	pendingEnrollments.Lock()
+	pendingEnrollments.byUser[caller.username] = pendingEnrollment{secret: secret, recoveryCodes: hashes, expires: time.Now().Add(enrollmentTTL)}
+	pendingEnrollments.Unlock()
+	return &pb.EnrollTotpResponse{
+		Secret:        secret,
+		OtpauthUri:    totp.URI(totpIssuer, caller.username, secret),
+		RecoveryCodes: codes,
+		Message:       fmt.Sprintf("Add the secret to an authenticator app and confirm with a code within %s", enrollmentTTL),
+	}, nil
+}
+
+// ConfirmTotp turns on the caller's pending enrollment once they show a
+// code for the new secret.
+func (s *server) ConfirmTotp(ctx context.Context, req *pb.ConfirmTotpRequest) (*pb.ConfirmTotpResponse, error) {
+	caller := callerFrom(ctx)
+	log.Printf("Gateway: ConfirmTotp called for user %s", caller.username)
+	pendingEnrollments.Lock()
+	pending, ok := pendingEnrollments.byUser[caller.username]
+	pendingEnrollments.Unlock()
+	if !ok || time.Now().After(pending.expires) {
+		return nil, payerr.New(pb.ErrorReason_TOTP_ENROLLMENT_NOT_FOUND, "No enrollment is waiting; call EnrollTotp first").Err()
+	}
+	// The caller was just handed this secret, so a wrong code is a typo
+	// rather than a guess and does not count as a failed login.
+	step, valid := totp.Verify(pending.secret, req.OtpCode, time.Now())
+	if !valid {
+		return nil, payerr.New(pb.ErrorReason_INVALID_OTP, "Code does not match the new secret").Err()
+	}
+	_, err := UpdateUser(caller.username, func(u *User) error {
+		u.TOTPSecret = pending.secret
+		u.RecoveryCodes = pending.recoveryCodes
+		return nil
+	})
+	if err != nil {
+		log.Printf("Gateway: Could not save TOTP secret of %s: %v", caller.username, err)
+		return nil, payerr.New(pb.ErrorReason_STORAGE_FAILURE, "Failed to update gateway users file").Err()
+	}
+	pendingEnrollments.Lock()
+	delete(pendingEnrollments.byUser, caller.username)
+	pendingEnrollments.Unlock()
+	usedSteps.Lock()
+	usedSteps.last[caller.username] = step
+	usedSteps.Unlock()
+	audit("totp_enrolled", caller.username, peerHost(ctx), fmt.Sprintf("%d recovery codes issued", len(pending.recoveryCodes)))
+	return &pb.ConfirmTotpResponse{Message: "Two-factor authentication is on"}, nil
+}
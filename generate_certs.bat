@@ -19,6 +19,17 @@ openssl req -nodes -newkey rsa:2048 -keyout certs\client.key -out certs\client.c
 echo Signing Client certificate with CA...
 openssl x509 -req -in certs\client.csr -CA certs\ca.pem -CAkey certs\ca.key -CAcreateserial -out certs\client.pem -days 365
 
+REM Each bank gets its own certificate: the gateway only accepts BankRegister
+REM from a certificate with CN=<bank name> and OU=bank.
+for %%B in (ICICI SBI) do (
+  echo Generating %%B bank key and certificate...
+  openssl req -nodes -newkey rsa:2048 -keyout certs\%%B.key -out certs\%%B.csr -subj "/CN=%%B/OU=bank"
+  openssl x509 -req -in certs\%%B.csr -CA certs\ca.pem -CAkey certs\ca.key -CAcreateserial -out certs\%%B.pem -days 365
+)
+
+echo Generating session token signing key...
+openssl rand -hex 32 > certs\token.key
+
 echo Cleaning up temporary CSR and serial files...
 del certs\*.csr
 del certs\*.srl
@@ -0,0 +1,296 @@
+// Package journal reads and writes the lines of a bank's double-entry
+// ledger, <BANK>_ledger.txt, and makes its history tamper-evident.
+//
+// Each line is one entry:
+//
+//	id,time,kind,reference,account,side,amount[,account,side,amount...],prev
+//
+// where side is D (debit) or C (credit) and prev is the SHA-256, in hex, of
+// the line before it (Genesis for the first line). Changing, removing or
+// reordering any line therefore breaks the link of the line after it.
+//
+// Anyone who can edit the file can also recompute every link after an
+// edit, so the bank signs a checkpoint from time to time:
+//
+//	id,time,checkpoint,bank,signature,prev
+//
+// The signature, made with the bank's TLS key, covers the bank name, the
+// checkpoint's id and time and prev, and so every line before it. Lines
+// after the last checkpoint are only chained until the next one is signed.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+)
+
+// Genesis is the prev of the first line of a ledger.
+var Genesis = strings.Repeat("0", sha256.Size*2)
+
+// KindCheckpoint marks a signed checkpoint. Other kinds are chosen by the
+// bank; every entry of another kind must balance.
+const KindCheckpoint = "checkpoint"
+
+// Sides of a posting.
+const (
+	SideDebit  = "D"
+	SideCredit = "C"
+)
+
+// Posting is one line of an entry.
+type Posting struct {
+	Account string
+	Side    string
+	Amount  *pb.Money
+}
+
+// Entry is one line of a ledger.
+type Entry struct {
+	ID        uint64
+	Time      time.Time
+	Kind      string
+	Reference string // the bank's name on a checkpoint
+	Postings  []Posting
+	Signature []byte // checkpoints only
+	Prev      string
+}
+
+// Encode renders e as a line, newline included.
+func (e Entry) Encode() ([]byte, error) {
+	record := []string{strconv.FormatUint(e.ID, 10), e.Time.UTC().Format(time.RFC3339Nano), e.Kind, e.Reference}
+	for _, p := range e.Postings {
+		record = append(record, p.Account, p.Side, money.Format(p.Amount))
+	}
+	if e.Kind == KindCheckpoint {
+		record = append(record, base64.StdEncoding.EncodeToString(e.Signature))
+	}
+	record = append(record, e.Prev)
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(record)
+	w.Flush()
+	// Lines are read back one at a time.
+	if bytes.Count(buf.Bytes(), []byte("\n")) != 1 {
+		return nil, fmt.Errorf("entry %s %q contains a line break", e.Kind, e.Reference)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode parses a line without its newline.
+func Decode(line []byte) (Entry, error) {
+	record, err := csv.NewReader(bytes.NewReader(line)).Read()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(record) < 4 {
+		return Entry{}, fmt.Errorf("entry has %d fields", len(record))
+	}
+	var e Entry
+	if e.ID, err = strconv.ParseUint(record[0], 10, 64); err != nil {
+		return Entry{}, fmt.Errorf("bad entry id %q", record[0])
+	}
+	if e.Time, err = time.Parse(time.RFC3339Nano, record[1]); err != nil {
+		return Entry{}, fmt.Errorf("bad time %q", record[1])
+	}
+	e.Kind, e.Reference = record[2], record[3]
+	rest := record[4:]
+	if e.Kind == KindCheckpoint {
+		if len(rest) != 2 {
+			return Entry{}, fmt.Errorf("checkpoint has %d fields", len(record))
+		}
+		if e.Signature, err = base64.StdEncoding.DecodeString(rest[0]); err != nil {
+			return Entry{}, fmt.Errorf("bad signature: %v", err)
+		}
+		e.Prev = rest[1]
+		return e, nil
+	}
+	if len(rest)%3 != 1 {
+		return Entry{}, fmt.Errorf("entry has %d fields", len(record))
+	}
+	e.Prev = rest[len(rest)-1]
+	rest = rest[:len(rest)-1]
+	for i := 0; i < len(rest); i += 3 {
+		amount, err := money.Parse(rest[i+2])
+		if err != nil {
+			return Entry{}, err
+		}
+		e.Postings = append(e.Postings, Posting{Account: rest[i], Side: rest[i+1], Amount: amount})
+	}
+	return e, nil
+}
+
+// Hash is the link to line, given without its newline.
+func Hash(line []byte) string {
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckBalanced reports an entry whose debits and credits differ in some
+// currency.
+func (e Entry) CheckBalanced() error {
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("entry %s %s has %d posting(s)", e.Kind, e.Reference, len(e.Postings))
+	}
+	net := make(map[string]int64)
+	for _, p := range e.Postings {
+		if err := money.ValidatePositive(p.Amount); err != nil {
+			return fmt.Errorf("entry %s %s: %v", e.Kind, e.Reference, err)
+		}
+		switch p.Side {
+		case SideDebit:
+			net[p.Amount.CurrencyCode] -= p.Amount.MinorUnits
+		case SideCredit:
+			net[p.Amount.CurrencyCode] += p.Amount.MinorUnits
+		default:
+			return fmt.Errorf("entry %s %s: unknown side %q", e.Kind, e.Reference, p.Side)
+		}
+	}
+	for currency, n := range net {
+		if n != 0 {
+			return fmt.Errorf("entry %s %s does not balance: credits exceed debits by %s", e.Kind, e.Reference, money.Format(money.New(n, currency)))
+		}
+	}
+	return nil
+}
+
+// checkpointPayload is what the signature of checkpoint e covers.
+func checkpointPayload(e Entry) []byte {
+	return []byte(fmt.Sprintf("payment_gateway ledger checkpoint\n%s\n%d\n%s\n%s\n",
+		e.Reference, e.ID, e.Time.UTC().Format(time.RFC3339Nano), e.Prev))
+}
+
+// Sign fills in the signature of checkpoint e.
+func Sign(e *Entry, key crypto.Signer) error {
+	payload := checkpointPayload(*e)
+	var err error
+	if _, ok := key.Public().(ed25519.PublicKey); ok {
+		e.Signature, err = key.Sign(rand.Reader, payload, crypto.Hash(0))
+		return err
+	}
+	digest := sha256.Sum256(payload)
+	e.Signature, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	return err
+}
+
+// VerifySignature checks the signature of checkpoint e against pub.
+func VerifySignature(e Entry, pub crypto.PublicKey) error {
+	payload := checkpointPayload(e)
+	digest := sha256.Sum256(payload)
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], e.Signature)
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], e.Signature) {
+			return errors.New("ecdsa: verification error")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, payload, e.Signature) {
+			return errors.New("ed25519: verification error")
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported key type %T", pub)
+}
+
+// ErrTorn is returned by Reader.Next for a last line without its newline,
+// which is what a crash in the middle of a write leaves.
+var ErrTorn = errors.New("torn entry at the end of the ledger")
+
+// BrokenLink describes the first line that does not follow from the lines
+// before it.
+type BrokenLink struct {
+	Line   int
+	Reason string
+}
+
+func (b *BrokenLink) Error() string {
+	return fmt.Sprintf("line %d: %s", b.Line, b.Reason)
+}
+
+// Reader reads a ledger line by line, checking that each line is well
+// formed, follows the previous one in id and link, and balances.
+// Signatures are left to the caller, who knows the bank's key.
+type Reader struct {
+	r      *bufio.Reader
+	line   int
+	offset int64
+	lastID uint64
+	last   string
+}
+
+// NewReader starts reading a ledger at its first line.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), last: Genesis}
+}
+
+// Next returns the next entry. It returns io.EOF after the last whole line,
+// ErrTorn if a partial line follows it, and a *BrokenLink for a line that
+// does not follow from the ones before it.
+func (r *Reader) Next() (Entry, error) {
+	line, err := r.r.ReadBytes('\n')
+	if err == io.EOF {
+		if len(line) > 0 {
+			return Entry{}, ErrTorn
+		}
+		return Entry{}, io.EOF
+	}
+	if err != nil {
+		return Entry{}, err
+	}
+	r.line++
+	line = line[:len(line)-1]
+	broken := func(format string, args ...interface{}) (Entry, error) {
+		return Entry{}, &BrokenLink{Line: r.line, Reason: fmt.Sprintf(format, args...)}
+	}
+	e, err := Decode(line)
+	if err != nil {
+		return broken("%v", err)
+	}
+	if e.ID != r.lastID+1 {
+		return broken("entry %d follows entry %d", e.ID, r.lastID)
+	}
+	if e.Prev != r.last {
+		return broken("entry %d links to %s, but the entry before it hashes to %s", e.ID, e.Prev, r.last)
+	}
+	if e.Kind != KindCheckpoint {
+		if err := e.CheckBalanced(); err != nil {
+			return broken("%v", err)
+		}
+	}
+	r.last = Hash(line)
+	r.lastID = e.ID
+	r.offset += int64(len(line)) + 1
+	return e, nil
+}
+
+// Line is the number of the last line returned.
+func (r *Reader) Line() int { return r.line }
+
+// Offset is the length of the ledger up to the end of the last entry
+// returned.
+func (r *Reader) Offset() int64 { return r.offset }
+
+// LastID is the id of the last entry returned.
+func (r *Reader) LastID() uint64 { return r.lastID }
+
+// LastHash is what the entry after the last one returned must link to.
+func (r *Reader) LastHash() string { return r.last }
@@ -0,0 +1,197 @@
+// migrate_money converts amounts in existing bank files from floating point
+// text to exact money values. It is meant to be run once, with the bank
+// servers stopped, from this directory:
+//
+//	go run migrate_money.go -banks=ICICI,SBI
+//
+// <BANK>_users.txt gets the balance_minor and currency columns in place of
+// balance; the amount columns of <BANK>_transactions.txt and
+// <BANK>_prepared.txt get the "<amount> <currency>" text form. Each
+// rewritten file is first copied to <file>.bak. Values already in the new
+// form are left alone, so running it twice is harmless.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"payment_gateway/money"
+	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
+)
+
+var (
+	dataDir  = flag.String("dir", "..", "Directory holding the <BANK>_*.txt files")
+	banks    = flag.String("banks", "", "Comma-separated bank names (default: every <BANK>_users.txt in -dir except the gateway's)")
+	currency = flag.String("currency", money.DefaultCurrency, "Currency of the existing amounts")
+	dryRun   = flag.Bool("dry-run", false, "Report what would change without writing")
+)
+
+const (
+	legacyUsersHeader = "AccountId,username,password,bank_name,balance"
+	usersHeader       = "AccountId,username,password,bank_name,balance_minor,currency"
+)
+
+// convertAmount reads an old floating point amount. Values written with
+// %.2f parse exactly; values with more digits than the currency allows were
+// float artifacts and are rounded to the nearest minor unit.
+func convertAmount(s string) (*pb.Money, error) {
+	if m, err := money.ParseDecimal(s, *currency); err == nil {
+		return m, nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	exp, _ := money.Exponent(*currency)
+	minor := math.Round(f * math.Pow10(exp))
+	if minor < 0 || minor > math.MaxInt64 {
+		return nil, fmt.Errorf("amount %q is out of range", s)
+	}
+	m := money.New(int64(minor), *currency)
+	log.Printf("[Migrate]   rounded %s to %s", s, money.Format(m))
+	return m, nil
+}
+
+// migrateUsers handles AccountId,username,password,bank_name,balance.
+func migrateUsers(records [][]string) (int, error) {
+	if len(records) == 0 || strings.Join(records[0], ",") == usersHeader {
+		return 0, nil
+	}
+	if strings.Join(records[0], ",") != legacyUsersHeader {
+		return 0, fmt.Errorf("unexpected header %q", strings.Join(records[0], ","))
+	}
+	records[0] = strings.Split(usersHeader, ",")
+	for i := 1; i < len(records); i++ {
+		if len(records[i]) != 5 {
+			return i, fmt.Errorf("line %d: expected 5 fields, got %d", i+1, len(records[i]))
+		}
+		balance, err := convertAmount(records[i][4])
+		if err != nil {
+			return i, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		records[i] = append(records[i][:4], strconv.FormatInt(balance.MinorUnits, 10), balance.CurrencyCode)
+	}
+	return len(records), nil
+}
+
+// migrateAmountColumn converts column col of every record.
+func migrateAmountColumn(col int) func([][]string) (int, error) {
+	return func(records [][]string) (int, error) {
+		changed := 0
+		for i, record := range records {
+			if len(record) <= col {
+				return changed, fmt.Errorf("line %d: expected at least %d fields, got %d", i+1, col+1, len(record))
+			}
+			if _, err := money.Parse(record[col]); err == nil {
+				continue
+			}
+			amount, err := convertAmount(record[col])
+			if err != nil {
+				return changed, fmt.Errorf("line %d: %v", i+1, err)
+			}
+			records[i][col] = money.Format(amount)
+			changed++
+		}
+		return changed, nil
+	}
+}
+
+func migrateFile(filename string, migrate func([][]string) (int, error)) error {
+	// Files the servers rewrite end in a checksum line, which this strips.
+	data, err := safefile.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	log.Printf("[Migrate] %s: %d record(s)", filename, len(records))
+	changed, err := migrate(records)
+	if err != nil {
+		return err
+	}
+	if changed == 0 || *dryRun {
+		log.Printf("[Migrate] %s: %d line(s) to rewrite, nothing written", filename, changed)
+		return nil
+	}
+	if err := os.WriteFile(filename+".bak", data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	if err := safefile.Replace(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Printf("[Migrate] %s: rewrote %d line(s); original saved as %s.bak", filename, changed, filepath.Base(filename))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	if _, ok := money.Exponent(*currency); !ok {
+		log.Fatalf("[Migrate] Unsupported currency %q", *currency)
+	}
+	var names []string
+	if *banks != "" {
+		names = strings.Split(*banks, ",")
+	} else {
+		matches, err := filepath.Glob(filepath.Join(*dataDir, "*_users.txt"))
+		if err != nil {
+			log.Fatalf("[Migrate] %v", err)
+		}
+		for _, m := range matches {
+			// gateway_users.txt holds gateway logins, not accounts.
+			if name := strings.TrimSuffix(filepath.Base(m), "_users.txt"); name != "gateway" {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(names) == 0 {
+		log.Printf("[Migrate] No bank files found in %s", *dataDir)
+		return
+	}
+	for _, bank := range names {
+		bank = strings.TrimSpace(bank)
+		files := []struct {
+			name    string
+			migrate func([][]string) (int, error)
+		}{
+			{fmt.Sprintf("%s_users.txt", bank), migrateUsers},
+			// key,sender,receiver,amount,operation
+			{fmt.Sprintf("%s_transactions.txt", bank), migrateAmountColumn(3)},
+			// key,transaction_id,operation,account,counterparty,amount,prepared_at,deadline
+			{fmt.Sprintf("%s_prepared.txt", bank), migrateAmountColumn(5)},
+		}
+		for _, file := range files {
+			filename := filepath.Join(*dataDir, file.name)
+			if err := migrateFile(filename, file.migrate); err != nil {
+				log.Fatalf("[Migrate] %s: %v", filename, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// migrate_txn_keys rewrites the idempotency keys in existing bank files from
+// the old suffixed form (e.g. TXN-000001-debit-debit) to the structured
+// <global_id>:<leg>:<leg_index> form. It is meant to be run once, with the
+// bank servers stopped, from this directory:
+//
+//	go run migrate_txn_keys.go -banks=ICICI,SBI
+//
+// Each rewritten file is first copied to <file>.bak. Keys that are already
+// in the new form are left alone, so running it twice is harmless.
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"payment_gateway/safefile"
+	"payment_gateway/txnkey"
+)
+
+var (
+	dataDir = flag.String("dir", "..", "Directory holding the <BANK>_*.txt files")
+	banks   = flag.String("banks", "", "Comma-separated bank names (default: every <BANK>_transactions.txt in -dir)")
+	dryRun  = flag.Bool("dry-run", false, "Report what would change without writing")
+)
+
+// migrateKey converts an old-style key to the canonical form. The leg comes
+// from the operation recorded alongside it rather than from the suffixes,
+// which older gateways and banks appended once each.
+func migrateKey(oldKey, operation string) (string, error) {
+	if _, err := txnkey.Parse(oldKey); err == nil {
+		return oldKey, nil
+	}
+	leg, ok := txnkey.ParseLeg(operation)
+	if !ok {
+		return "", fmt.Errorf("unknown operation %q for key %q", operation, oldKey)
+	}
+	globalID := oldKey
+	for {
+		trimmed := strings.TrimSuffix(strings.TrimSuffix(globalID, "-debit"), "-credit")
+		if trimmed == globalID {
+			break
+		}
+		globalID = trimmed
+	}
+	key := txnkey.New(globalID, leg, 0)
+	if err := txnkey.Validate(key, leg); err != nil {
+		return "", fmt.Errorf("cannot migrate key %q: %v", oldKey, err)
+	}
+	return txnkey.Format(key), nil
+}
+
+// migrateTransactions handles lines of the form
+// key,sender,receiver,amount,operation.
+func migrateTransactions(records [][]string) (int, error) {
+	changed := 0
+	for i, record := range records {
+		if len(record) < 5 {
+			return changed, fmt.Errorf("line %d: expected at least 5 fields, got %d", i+1, len(record))
+		}
+		newKey, err := migrateKey(record[0], record[4])
+		if err != nil {
+			return changed, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if newKey != record[0] {
+			log.Printf("[Migrate]   %s -> %s", record[0], newKey)
+			records[i][0] = newKey
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// migratePrepared handles lines of the form
+// key,transaction_id,operation,account,counterparty,amount,prepared_at,deadline.
+func migratePrepared(records [][]string) (int, error) {
+	changed := 0
+	for i, record := range records {
+		if len(record) < 3 {
+			return changed, fmt.Errorf("line %d: expected at least 3 fields, got %d", i+1, len(record))
+		}
+		newKey, err := migrateKey(record[0], record[2])
+		if err != nil {
+			return changed, fmt.Errorf("line %d: %v", i+1, err)
+		}
+		if newKey != record[0] {
+			key, _ := txnkey.Parse(newKey)
+			log.Printf("[Migrate]   %s -> %s", record[0], newKey)
+			records[i][0] = newKey
+			records[i][1] = key.GlobalId
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func migrateFile(filename string, migrate func([][]string) (int, error)) error {
+	// Files the servers rewrite end in a checksum line, which this strips.
+	data, err := safefile.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	var records [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+	}
+	log.Printf("[Migrate] %s: %d record(s)", filename, len(records))
+	changed, err := migrate(records)
+	if err != nil {
+		return err
+	}
+	if changed == 0 || *dryRun {
+		log.Printf("[Migrate] %s: %d key(s) to rewrite, nothing written", filename, changed)
+		return nil
+	}
+	if err := os.WriteFile(filename+".bak", data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := csv.NewWriter(&buf).WriteAll(records); err != nil {
+		return err
+	}
+	if err := safefile.Replace(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Printf("[Migrate] %s: rewrote %d key(s); original saved as %s.bak", filename, changed, filepath.Base(filename))
+	return nil
+}
+
+func main() {
+	flag.Parse()
+	var names []string
+	if *banks != "" {
+		names = strings.Split(*banks, ",")
+	} else {
+		matches, err := filepath.Glob(filepath.Join(*dataDir, "*_transactions.txt"))
+		if err != nil {
+			log.Fatalf("[Migrate] %v", err)
+		}
+		for _, m := range matches {
+			names = append(names, strings.TrimSuffix(filepath.Base(m), "_transactions.txt"))
+		}
+	}
+	if len(names) == 0 {
+		log.Printf("[Migrate] No bank files found in %s", *dataDir)
+		return
+	}
+	for _, bank := range names {
+		bank = strings.TrimSpace(bank)
+		transactions := filepath.Join(*dataDir, fmt.Sprintf("%s_transactions.txt", bank))
+		if err := migrateFile(transactions, migrateTransactions); err != nil {
+			log.Fatalf("[Migrate] %s: %v", transactions, err)
+		}
+		prepared := filepath.Join(*dataDir, fmt.Sprintf("%s_prepared.txt", bank))
+		if err := migrateFile(prepared, migratePrepared); err != nil {
+			log.Fatalf("[Migrate] %s: %v", prepared, err)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package money does exact arithmetic on pb.Money amounts: integer minor
+// units plus an ISO 4217 currency code. Amounts never pass through floating
+// point. The text form used in files and logs is the decimal amount followed
+// by the currency code, e.g. "1575.00 INR".
+package money
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	pb "payment_gateway/proto"
+)
+
+// DefaultCurrency is used where no currency is given, e.g. by clients.
+const DefaultCurrency = "INR"
+
+// exponents lists the supported currencies and the number of decimal digits
+// of their minor unit.
+var exponents = map[string]int{
+	"INR": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"JPY": 0,
+}
+
+// Exponent returns the number of minor-unit digits of currency.
+func Exponent(currency string) (int, bool) {
+	exp, ok := exponents[currency]
+	return exp, ok
+}
+
+// New returns an amount of minorUnits in currency.
+func New(minorUnits int64, currency string) *pb.Money {
+	return &pb.Money{CurrencyCode: currency, MinorUnits: minorUnits}
+}
+
+// Zero returns a zero amount in currency.
+func Zero(currency string) *pb.Money {
+	return New(0, currency)
+}
+
+// Validate checks that m is present, in a supported currency and not
+// negative.
+func Validate(m *pb.Money) error {
+	if m == nil {
+		return fmt.Errorf("amount is missing")
+	}
+	if _, ok := Exponent(m.CurrencyCode); !ok {
+		return fmt.Errorf("unsupported currency %q", m.CurrencyCode)
+	}
+	if m.MinorUnits < 0 {
+		return fmt.Errorf("amount %s is negative", Format(m))
+	}
+	return nil
+}
+
+// ValidatePositive is Validate for amounts that must be above zero, such as
+// the amount of a transfer.
+func ValidatePositive(m *pb.Money) error {
+	if err := Validate(m); err != nil {
+		return err
+	}
+	if m.MinorUnits == 0 {
+		return fmt.Errorf("amount must be greater than zero")
+	}
+	return nil
+}
+
+// ParseDecimal reads a non-negative decimal such as "12", "12.5" or "12.50"
+// as an amount in currency. More decimal places than the currency's minor
+// unit allows are rejected rather than rounded.
+func ParseDecimal(s, currency string) (*pb.Money, error) {
+	exp, ok := Exponent(currency)
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency %q", currency)
+	}
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "-") {
+		return nil, fmt.Errorf("amount %s is negative", s)
+	}
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" || (hasPoint && frac == "") || !isDigits(whole) || !isDigits(frac) {
+		return nil, fmt.Errorf("amount %q is not a decimal number", s)
+	}
+	if len(frac) > exp {
+		return nil, fmt.Errorf("amount %s is smaller than the minor unit of %s (%d decimal places)", s, currency, exp)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("amount %s is out of range", s)
+	}
+	return New(minor, currency), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse reads the text form produced by Format.
+func Parse(s string) (*pb.Money, error) {
+	amount, currency, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return nil, fmt.Errorf("amount %q has no currency code", s)
+	}
+	return ParseDecimal(amount, currency)
+}
+
+// FormatDecimal renders m without its currency, e.g. "1575.00".
+func FormatDecimal(m *pb.Money) string {
+	exp, ok := Exponent(m.GetCurrencyCode())
+	if !ok {
+		return strconv.FormatInt(m.GetMinorUnits(), 10)
+	}
+	minor := m.GetMinorUnits()
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUint(minor), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+func absUint(v int64) uint64 {
+	if v < 0 {
+		return uint64(-(v + 1)) + 1
+	}
+	return uint64(v)
+}
+
+// Format renders m in its text form, e.g. "1575.00 INR".
+func Format(m *pb.Money) string {
+	return FormatDecimal(m) + " " + m.GetCurrencyCode()
+}
+
+func sameCurrency(a, b *pb.Money) error {
+	if a.GetCurrencyCode() != b.GetCurrencyCode() {
+		return fmt.Errorf("currency mismatch: %s and %s", a.GetCurrencyCode(), b.GetCurrencyCode())
+	}
+	return nil
+}
+
+// Add returns a+b.
+func Add(a, b *pb.Money) (*pb.Money, error) {
+	if err := sameCurrency(a, b); err != nil {
+		return nil, err
+	}
+	x, y := a.GetMinorUnits(), b.GetMinorUnits()
+	if (y > 0 && x > math.MaxInt64-y) || (y < 0 && x < math.MinInt64-y) {
+		return nil, fmt.Errorf("amount overflow adding %s and %s", Format(a), Format(b))
+	}
+	return New(x+y, a.GetCurrencyCode()), nil
+}
+
+// Sub returns a-b, which may be negative.
+func Sub(a, b *pb.Money) (*pb.Money, error) {
+	if err := sameCurrency(a, b); err != nil {
+		return nil, err
+	}
+	x, y := a.GetMinorUnits(), b.GetMinorUnits()
+	if (y < 0 && x > math.MaxInt64+y) || (y > 0 && x < math.MinInt64+y) {
+		return nil, fmt.Errorf("amount overflow subtracting %s from %s", Format(b), Format(a))
+	}
+	return New(x-y, a.GetCurrencyCode()), nil
+}
+
+// Cmp compares a and b, returning -1, 0 or +1.
+func Cmp(a, b *pb.Money) (int, error) {
+	if err := sameCurrency(a, b); err != nil {
+		return 0, err
+	}
+	switch x, y := a.GetMinorUnits(), b.GetMinorUnits(); {
+	case x < y:
+		return -1, nil
+	case x > y:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Equal reports whether a and b are the same amount in the same currency.
+func Equal(a, b *pb.Money) bool {
+	return a.GetCurrencyCode() == b.GetCurrencyCode() && a.GetMinorUnits() == b.GetMinorUnits()
+}
@@ -0,0 +1,50 @@
+// Package password hashes and checks user passwords. Hashes are bcrypt
+// strings, which carry their own salt and cost, so they can be stored in
+// the CSV password columns as they are.
+package password
+
+import (
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the longest password bcrypt hashes in full.
+const MaxLength = 72
+
+// dummyHash is compared against when a user does not exist, so that a
+// failed login takes as long whether or not the username is known.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Hash returns a salted hash of password.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Check reports whether password matches hash. An empty hash stands for an
+// unknown user and never matches.
+func Check(hash, password string) bool {
+	if hash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// IsHash reports whether s is a hash produced by Hash rather than a
+// plaintext password left over from before passwords were hashed.
+func IsHash(s string) bool {
+	if !strings.HasPrefix(s, "$2") {
+		return false
+	}
+	_, err := bcrypt.Cost([]byte(s))
+	return err == nil
+}
@@ -0,0 +1,132 @@
+// Package payerr builds and reads the errors returned by Bank and
+// PaymentGateway RPCs: a canonical gRPC status code plus a pb.ErrorDetail
+// saying why the call failed and whether it is worth retrying. Callers
+// branch on the detail rather than on the status message.
+package payerr
+
+import (
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	pb "payment_gateway/proto"
+)
+
+// reasons gives the status code and default retryability of each reason.
+var reasons = map[pb.ErrorReason]struct {
+	code      codes.Code
+	retryable bool
+}{
+	pb.ErrorReason_INVALID_REQUEST:                {codes.InvalidArgument, false},
+	pb.ErrorReason_INSUFFICIENT_FUNDS:             {codes.FailedPrecondition, false},
+	pb.ErrorReason_ACCOUNT_NOT_FOUND:              {codes.NotFound, false},
+	pb.ErrorReason_BANK_UNAVAILABLE:               {codes.Unavailable, true},
+	pb.ErrorReason_BANK_NOT_REGISTERED:            {codes.FailedPrecondition, false},
+	pb.ErrorReason_GATEWAY_UNAVAILABLE:            {codes.Unavailable, true},
+	pb.ErrorReason_SELF_TRANSFER:                  {codes.InvalidArgument, false},
+	pb.ErrorReason_CURRENCY_MISMATCH:              {codes.FailedPrecondition, false},
+	pb.ErrorReason_TRANSACTION_ID_REUSED:          {codes.AlreadyExists, false},
+	pb.ErrorReason_TRANSACTION_IN_PROGRESS:        {codes.Aborted, true},
+	pb.ErrorReason_TRANSACTION_ABORTED:            {codes.Aborted, false},
+	pb.ErrorReason_PREPARED_TRANSACTION_NOT_FOUND: {codes.FailedPrecondition, false},
+	pb.ErrorReason_STORAGE_FAILURE:                {codes.Internal, true},
+	pb.ErrorReason_UNAUTHENTICATED:                {codes.Unauthenticated, false},
+	pb.ErrorReason_INVALID_CREDENTIALS:            {codes.Unauthenticated, false},
+	pb.ErrorReason_TRANSFER_NOT_FOUND:             {codes.NotFound, false},
+	pb.ErrorReason_UNSUPPORTED_OPERATION:          {codes.Unimplemented, false},
+	pb.ErrorReason_ACCOUNT_NOT_OWNED:              {codes.PermissionDenied, false},
+	pb.ErrorReason_TOKEN_EXPIRED:                  {codes.Unauthenticated, false},
+	pb.ErrorReason_USERNAME_TAKEN:                 {codes.AlreadyExists, false},
+	pb.ErrorReason_ACCOUNT_EXISTS:                 {codes.AlreadyExists, false},
+	pb.ErrorReason_ACCOUNT_NOT_EMPTY:              {codes.FailedPrecondition, false},
+	pb.ErrorReason_LOGIN_THROTTLED:                {codes.ResourceExhausted, true},
+	pb.ErrorReason_LOGIN_LOCKED_OUT:               {codes.PermissionDenied, false},
+	pb.ErrorReason_OTP_REQUIRED:                   {codes.Unauthenticated, false},
+	pb.ErrorReason_INVALID_OTP:                    {codes.Unauthenticated, false},
+	pb.ErrorReason_TOTP_NOT_ENROLLED:              {codes.FailedPrecondition, false},
+	pb.ErrorReason_TOTP_ENROLLMENT_NOT_FOUND:      {codes.FailedPrecondition, false},
+	pb.ErrorReason_PIN_REQUIRED:                   {codes.Unauthenticated, false},
+	pb.ErrorReason_INVALID_PIN:                    {codes.Unauthenticated, false},
+	pb.ErrorReason_PIN_LOCKED_OUT:                 {codes.PermissionDenied, false},
+	pb.ErrorReason_BANK_IDENTITY_MISMATCH:         {codes.PermissionDenied, false},
+}
+
+// Error is a failed RPC in structured form.
+type Error struct {
+	Code      codes.Code
+	Reason    pb.ErrorReason
+	Retryable bool
+	BankName  string
+	Message   string
+	// RetryDelay, if set, is how long the caller should wait before trying
+	// again. It travels as a google.rpc.RetryInfo detail.
+	RetryDelay time.Duration
+}
+
+// New returns an error for reason with the reason's usual status code and
+// retryability.
+func New(reason pb.ErrorReason, msg string) *Error {
+	r, ok := reasons[reason]
+	if !ok {
+		r.code = codes.Unknown
+	}
+	return &Error{Code: r.code, Reason: reason, Retryable: r.retryable, Message: msg}
+}
+
+// Err returns e as a gRPC status error carrying an ErrorDetail.
+func (e *Error) Err() error {
+	st := status.New(e.Code, e.Message)
+	details := []protoadapt.MessageV1{&pb.ErrorDetail{
+		Reason:    e.Reason,
+		Retryable: e.Retryable,
+		BankName:  e.BankName,
+	}}
+	if e.RetryDelay > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(e.RetryDelay)})
+	}
+	detailed, err := st.WithDetails(details...)
+	if err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// From reads err back into an Error. Errors without an ErrorDetail, such as
+// transport failures, get ERROR_REASON_UNSPECIFIED and are retryable if
+// their code says the call may not have been processed.
+func From(err error) *Error {
+	st := status.Convert(err)
+	e := &Error{Code: st.Code(), Message: st.Message(), Retryable: retryableCode(st.Code())}
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *pb.ErrorDetail:
+			e.Reason, e.Retryable, e.BankName = d.Reason, d.Retryable, d.BankName
+		case *errdetails.RetryInfo:
+			e.RetryDelay = d.GetRetryDelay().AsDuration()
+		}
+	}
+	return e
+}
+
+func retryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// Reason returns the reason attached to err.
+func Reason(err error) pb.ErrorReason {
+	return From(err).Reason
+}
+
+// Retryable reports whether sending the request that failed with err again
+// may succeed.
+func Retryable(err error) bool {
+	return From(err).Retryable
+}
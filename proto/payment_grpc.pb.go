@@ -19,24 +19,69 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	PaymentGateway_Register_FullMethodName      = "/payment.PaymentGateway/Register"
-	PaymentGateway_Authenticate_FullMethodName  = "/payment.PaymentGateway/Authenticate"
-	PaymentGateway_TransferMoney_FullMethodName = "/payment.PaymentGateway/TransferMoney"
-	PaymentGateway_CheckBalance_FullMethodName  = "/payment.PaymentGateway/CheckBalance"
-	PaymentGateway_BankRegister_FullMethodName  = "/payment.PaymentGateway/BankRegister"
+	PaymentGateway_Register_FullMethodName               = "/payment.PaymentGateway/Register"
+	PaymentGateway_Authenticate_FullMethodName           = "/payment.PaymentGateway/Authenticate"
+	PaymentGateway_TransferMoney_FullMethodName          = "/payment.PaymentGateway/TransferMoney"
+	PaymentGateway_CheckBalance_FullMethodName           = "/payment.PaymentGateway/CheckBalance"
+	PaymentGateway_BankRegister_FullMethodName           = "/payment.PaymentGateway/BankRegister"
+	PaymentGateway_GetTransactionDecision_FullMethodName = "/payment.PaymentGateway/GetTransactionDecision"
+	PaymentGateway_GetTransferStatus_FullMethodName      = "/payment.PaymentGateway/GetTransferStatus"
+	PaymentGateway_SubmitTransfer_FullMethodName         = "/payment.PaymentGateway/SubmitTransfer"
+	PaymentGateway_WatchTransfer_FullMethodName          = "/payment.PaymentGateway/WatchTransfer"
+	PaymentGateway_RefreshToken_FullMethodName           = "/payment.PaymentGateway/RefreshToken"
+	PaymentGateway_Logout_FullMethodName                 = "/payment.PaymentGateway/Logout"
+	PaymentGateway_ChangePassword_FullMethodName         = "/payment.PaymentGateway/ChangePassword"
+	PaymentGateway_OpenAccount_FullMethodName            = "/payment.PaymentGateway/OpenAccount"
+	PaymentGateway_CloseAccount_FullMethodName           = "/payment.PaymentGateway/CloseAccount"
+	PaymentGateway_GetAccount_FullMethodName             = "/payment.PaymentGateway/GetAccount"
+	PaymentGateway_EnrollTotp_FullMethodName             = "/payment.PaymentGateway/EnrollTotp"
+	PaymentGateway_ConfirmTotp_FullMethodName            = "/payment.PaymentGateway/ConfirmTotp"
+	PaymentGateway_SetTransactionPin_FullMethodName      = "/payment.PaymentGateway/SetTransactionPin"
 )
 
 // PaymentGatewayClient is the client API for PaymentGateway service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Payment Gateway Service
+// Payment Gateway Service. Failed calls return a non-OK status with an
+// ErrorDetail; success fields in responses are always true.
 type PaymentGatewayClient interface {
 	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
 	Authenticate(ctx context.Context, in *AuthRequest, opts ...grpc.CallOption) (*AuthResponse, error)
 	TransferMoney(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
 	CheckBalance(ctx context.Context, in *BalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
 	BankRegister(ctx context.Context, in *BankRegisterRequest, opts ...grpc.CallOption) (*BankRegisterResponse, error)
+	// Lets a participant bank resolve an in-doubt prepared transaction.
+	GetTransactionDecision(ctx context.Context, in *TransactionDecisionRequest, opts ...grpc.CallOption) (*TransactionDecisionResponse, error)
+	// Reports where a transfer is in the two-phase commit, per leg.
+	GetTransferStatus(ctx context.Context, in *TransferStatusRequest, opts ...grpc.CallOption) (*TransferStatusResponse, error)
+	// Persists a transfer and returns at once; a gateway worker runs it.
+	SubmitTransfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*SubmitTransferResponse, error)
+	// Streams status changes of a transfer until it is committed or aborted.
+	WatchTransfer(ctx context.Context, in *TransferStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferStatusResponse], error)
+	// Exchanges a token that is valid or recently expired for a new one; the
+	// old token is revoked.
+	RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*AuthResponse, error)
+	// Revokes the token the call is made with.
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
+	// Changes the caller's password. Tokens issued before the change stop
+	// being accepted; the response carries a new one.
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*AuthResponse, error)
+	// Opens another account for the caller at a bank. Tokens issued before
+	// do not cover it; authenticate again to use it.
+	OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	// Closes one of the caller's accounts. It must be empty and have no
+	// transfer in progress.
+	CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*CloseAccountResponse, error)
+	// Returns the holder and currency of one of the caller's accounts.
+	GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	// Starts two-factor enrollment: returns a new TOTP secret and recovery
+	// codes. They take effect once ConfirmTotp receives a code for the secret.
+	EnrollTotp(ctx context.Context, in *EnrollTotpRequest, opts ...grpc.CallOption) (*EnrollTotpResponse, error)
+	ConfirmTotp(ctx context.Context, in *ConfirmTotpRequest, opts ...grpc.CallOption) (*ConfirmTotpResponse, error)
+	// Sets, replaces or removes the caller's transaction PIN. Once set,
+	// TransferMoney and SubmitTransfer need it in TransferRequest.pin.
+	SetTransactionPin(ctx context.Context, in *SetTransactionPinRequest, opts ...grpc.CallOption) (*SetTransactionPinResponse, error)
 }
 
 type paymentGatewayClient struct {
@@ -97,17 +142,188 @@ func (c *paymentGatewayClient) BankRegister(ctx context.Context, in *BankRegiste
 	return out, nil
 }
 
+func (c *paymentGatewayClient) GetTransactionDecision(ctx context.Context, in *TransactionDecisionRequest, opts ...grpc.CallOption) (*TransactionDecisionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactionDecisionResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_GetTransactionDecision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) GetTransferStatus(ctx context.Context, in *TransferStatusRequest, opts ...grpc.CallOption) (*TransferStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferStatusResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_GetTransferStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) SubmitTransfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*SubmitTransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitTransferResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_SubmitTransfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) WatchTransfer(ctx context.Context, in *TransferStatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TransferStatusResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PaymentGateway_ServiceDesc.Streams[0], PaymentGateway_WatchTransfer_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[TransferStatusRequest, TransferStatusResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentGateway_WatchTransferClient = grpc.ServerStreamingClient[TransferStatusResponse]
+
+func (c *paymentGatewayClient) RefreshToken(ctx context.Context, in *RefreshTokenRequest, opts ...grpc.CallOption) (*AuthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_RefreshToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogoutResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_Logout_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*AuthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AuthResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_ChangePassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_OpenAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*CloseAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseAccountResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_CloseAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) EnrollTotp(ctx context.Context, in *EnrollTotpRequest, opts ...grpc.CallOption) (*EnrollTotpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EnrollTotpResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_EnrollTotp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) ConfirmTotp(ctx context.Context, in *ConfirmTotpRequest, opts ...grpc.CallOption) (*ConfirmTotpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmTotpResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_ConfirmTotp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentGatewayClient) SetTransactionPin(ctx context.Context, in *SetTransactionPinRequest, opts ...grpc.CallOption) (*SetTransactionPinResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetTransactionPinResponse)
+	err := c.cc.Invoke(ctx, PaymentGateway_SetTransactionPin_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // PaymentGatewayServer is the server API for PaymentGateway service.
 // All implementations must embed UnimplementedPaymentGatewayServer
 // for forward compatibility.
 //
-// Payment Gateway Service
+// Payment Gateway Service. Failed calls return a non-OK status with an
+// ErrorDetail; success fields in responses are always true.
 type PaymentGatewayServer interface {
 	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
 	Authenticate(context.Context, *AuthRequest) (*AuthResponse, error)
 	TransferMoney(context.Context, *TransferRequest) (*TransferResponse, error)
 	CheckBalance(context.Context, *BalanceRequest) (*BalanceResponse, error)
 	BankRegister(context.Context, *BankRegisterRequest) (*BankRegisterResponse, error)
+	// Lets a participant bank resolve an in-doubt prepared transaction.
+	GetTransactionDecision(context.Context, *TransactionDecisionRequest) (*TransactionDecisionResponse, error)
+	// Reports where a transfer is in the two-phase commit, per leg.
+	GetTransferStatus(context.Context, *TransferStatusRequest) (*TransferStatusResponse, error)
+	// Persists a transfer and returns at once; a gateway worker runs it.
+	SubmitTransfer(context.Context, *TransferRequest) (*SubmitTransferResponse, error)
+	// Streams status changes of a transfer until it is committed or aborted.
+	WatchTransfer(*TransferStatusRequest, grpc.ServerStreamingServer[TransferStatusResponse]) error
+	// Exchanges a token that is valid or recently expired for a new one; the
+	// old token is revoked.
+	RefreshToken(context.Context, *RefreshTokenRequest) (*AuthResponse, error)
+	// Revokes the token the call is made with.
+	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
+	// Changes the caller's password. Tokens issued before the change stop
+	// being accepted; the response carries a new one.
+	ChangePassword(context.Context, *ChangePasswordRequest) (*AuthResponse, error)
+	// Opens another account for the caller at a bank. Tokens issued before
+	// do not cover it; authenticate again to use it.
+	OpenAccount(context.Context, *OpenAccountRequest) (*AccountResponse, error)
+	// Closes one of the caller's accounts. It must be empty and have no
+	// transfer in progress.
+	CloseAccount(context.Context, *AccountRequest) (*CloseAccountResponse, error)
+	// Returns the holder and currency of one of the caller's accounts.
+	GetAccount(context.Context, *AccountRequest) (*AccountResponse, error)
+	// Starts two-factor enrollment: returns a new TOTP secret and recovery
+	// codes. They take effect once ConfirmTotp receives a code for the secret.
+	EnrollTotp(context.Context, *EnrollTotpRequest) (*EnrollTotpResponse, error)
+	ConfirmTotp(context.Context, *ConfirmTotpRequest) (*ConfirmTotpResponse, error)
+	// Sets, replaces or removes the caller's transaction PIN. Once set,
+	// TransferMoney and SubmitTransfer need it in TransferRequest.pin.
+	SetTransactionPin(context.Context, *SetTransactionPinRequest) (*SetTransactionPinResponse, error)
 	mustEmbedUnimplementedPaymentGatewayServer()
 }
 
@@ -133,6 +349,45 @@ func (UnimplementedPaymentGatewayServer) CheckBalance(context.Context, *BalanceR
 func (UnimplementedPaymentGatewayServer) BankRegister(context.Context, *BankRegisterRequest) (*BankRegisterResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BankRegister not implemented")
 }
+func (UnimplementedPaymentGatewayServer) GetTransactionDecision(context.Context, *TransactionDecisionRequest) (*TransactionDecisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactionDecision not implemented")
+}
+func (UnimplementedPaymentGatewayServer) GetTransferStatus(context.Context, *TransferStatusRequest) (*TransferStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransferStatus not implemented")
+}
+func (UnimplementedPaymentGatewayServer) SubmitTransfer(context.Context, *TransferRequest) (*SubmitTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitTransfer not implemented")
+}
+func (UnimplementedPaymentGatewayServer) WatchTransfer(*TransferStatusRequest, grpc.ServerStreamingServer[TransferStatusResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchTransfer not implemented")
+}
+func (UnimplementedPaymentGatewayServer) RefreshToken(context.Context, *RefreshTokenRequest) (*AuthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefreshToken not implemented")
+}
+func (UnimplementedPaymentGatewayServer) Logout(context.Context, *LogoutRequest) (*LogoutResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedPaymentGatewayServer) ChangePassword(context.Context, *ChangePasswordRequest) (*AuthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedPaymentGatewayServer) OpenAccount(context.Context, *OpenAccountRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenAccount not implemented")
+}
+func (UnimplementedPaymentGatewayServer) CloseAccount(context.Context, *AccountRequest) (*CloseAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseAccount not implemented")
+}
+func (UnimplementedPaymentGatewayServer) GetAccount(context.Context, *AccountRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedPaymentGatewayServer) EnrollTotp(context.Context, *EnrollTotpRequest) (*EnrollTotpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTotp not implemented")
+}
+func (UnimplementedPaymentGatewayServer) ConfirmTotp(context.Context, *ConfirmTotpRequest) (*ConfirmTotpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTotp not implemented")
+}
+func (UnimplementedPaymentGatewayServer) SetTransactionPin(context.Context, *SetTransactionPinRequest) (*SetTransactionPinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetTransactionPin not implemented")
+}
 func (UnimplementedPaymentGatewayServer) mustEmbedUnimplementedPaymentGatewayServer() {}
 func (UnimplementedPaymentGatewayServer) testEmbeddedByValue()                        {}
 
@@ -244,6 +499,233 @@ func _PaymentGateway_BankRegister_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _PaymentGateway_GetTransactionDecision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactionDecisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).GetTransactionDecision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_GetTransactionDecision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).GetTransactionDecision(ctx, req.(*TransactionDecisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_GetTransferStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).GetTransferStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_GetTransferStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).GetTransferStatus(ctx, req.(*TransferStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_SubmitTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).SubmitTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_SubmitTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).SubmitTransfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_WatchTransfer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TransferStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentGatewayServer).WatchTransfer(m, &grpc.GenericServerStream[TransferStatusRequest, TransferStatusResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PaymentGateway_WatchTransferServer = grpc.ServerStreamingServer[TransferStatusResponse]
+
+func _PaymentGateway_RefreshToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefreshTokenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).RefreshToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_RefreshToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).RefreshToken(ctx, req.(*RefreshTokenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_OpenAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).OpenAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_OpenAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).OpenAccount(ctx, req.(*OpenAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_CloseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).CloseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_CloseAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).CloseAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).GetAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_EnrollTotp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnrollTotpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).EnrollTotp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_EnrollTotp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).EnrollTotp(ctx, req.(*EnrollTotpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_ConfirmTotp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmTotpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).ConfirmTotp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_ConfirmTotp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).ConfirmTotp(ctx, req.(*ConfirmTotpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentGateway_SetTransactionPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetTransactionPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentGatewayServer).SetTransactionPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentGateway_SetTransactionPin_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentGatewayServer).SetTransactionPin(ctx, req.(*SetTransactionPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // PaymentGateway_ServiceDesc is the grpc.ServiceDesc for PaymentGateway service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -271,32 +753,102 @@ var PaymentGateway_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "BankRegister",
 			Handler:    _PaymentGateway_BankRegister_Handler,
 		},
+		{
+			MethodName: "GetTransactionDecision",
+			Handler:    _PaymentGateway_GetTransactionDecision_Handler,
+		},
+		{
+			MethodName: "GetTransferStatus",
+			Handler:    _PaymentGateway_GetTransferStatus_Handler,
+		},
+		{
+			MethodName: "SubmitTransfer",
+			Handler:    _PaymentGateway_SubmitTransfer_Handler,
+		},
+		{
+			MethodName: "RefreshToken",
+			Handler:    _PaymentGateway_RefreshToken_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _PaymentGateway_Logout_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _PaymentGateway_ChangePassword_Handler,
+		},
+		{
+			MethodName: "OpenAccount",
+			Handler:    _PaymentGateway_OpenAccount_Handler,
+		},
+		{
+			MethodName: "CloseAccount",
+			Handler:    _PaymentGateway_CloseAccount_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _PaymentGateway_GetAccount_Handler,
+		},
+		{
+			MethodName: "EnrollTotp",
+			Handler:    _PaymentGateway_EnrollTotp_Handler,
+		},
+		{
+			MethodName: "ConfirmTotp",
+			Handler:    _PaymentGateway_ConfirmTotp_Handler,
+		},
+		{
+			MethodName: "SetTransactionPin",
+			Handler:    _PaymentGateway_SetTransactionPin_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchTransfer",
+			Handler:       _PaymentGateway_WatchTransfer_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "payment.proto",
 }
 
 const (
-	Bank_ProcessTransaction_FullMethodName = "/payment.Bank/ProcessTransaction"
-	Bank_GetBalance_FullMethodName         = "/payment.Bank/GetBalance"
-	Bank_DebitAccount_FullMethodName       = "/payment.Bank/DebitAccount"
-	Bank_CreditAccount_FullMethodName      = "/payment.Bank/CreditAccount"
-	Bank_PrepareDebit_FullMethodName       = "/payment.Bank/PrepareDebit"
-	Bank_CommitDebit_FullMethodName        = "/payment.Bank/CommitDebit"
-	Bank_AbortDebit_FullMethodName         = "/payment.Bank/AbortDebit"
-	Bank_PrepareCredit_FullMethodName      = "/payment.Bank/PrepareCredit"
-	Bank_CommitCredit_FullMethodName       = "/payment.Bank/CommitCredit"
-	Bank_AbortCredit_FullMethodName        = "/payment.Bank/AbortCredit"
+	Bank_ProcessTransaction_FullMethodName  = "/payment.Bank/ProcessTransaction"
+	Bank_GetBalance_FullMethodName          = "/payment.Bank/GetBalance"
+	Bank_GetAccount_FullMethodName          = "/payment.Bank/GetAccount"
+	Bank_VerifyAccountHolder_FullMethodName = "/payment.Bank/VerifyAccountHolder"
+	Bank_OpenAccount_FullMethodName         = "/payment.Bank/OpenAccount"
+	Bank_CloseAccount_FullMethodName        = "/payment.Bank/CloseAccount"
+	Bank_DebitAccount_FullMethodName        = "/payment.Bank/DebitAccount"
+	Bank_CreditAccount_FullMethodName       = "/payment.Bank/CreditAccount"
+	Bank_PrepareDebit_FullMethodName        = "/payment.Bank/PrepareDebit"
+	Bank_CommitDebit_FullMethodName         = "/payment.Bank/CommitDebit"
+	Bank_AbortDebit_FullMethodName          = "/payment.Bank/AbortDebit"
+	Bank_PrepareCredit_FullMethodName       = "/payment.Bank/PrepareCredit"
+	Bank_CommitCredit_FullMethodName        = "/payment.Bank/CommitCredit"
+	Bank_AbortCredit_FullMethodName         = "/payment.Bank/AbortCredit"
 )
 
 // BankClient is the client API for Bank service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// Bank Service
+// Bank Service. Failed calls return a non-OK status with an ErrorDetail.
 type BankClient interface {
 	ProcessTransaction(ctx context.Context, in *TransactionRequest, opts ...grpc.CallOption) (*TransactionResponse, error)
 	GetBalance(ctx context.Context, in *BalanceRequest, opts ...grpc.CallOption) (*BalanceResponse, error)
+	// Looks up an account holder.
+	GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	// Checks that username holds the account and that password matches its
+	// bank password. Accounts opened with OpenAccount have no bank password
+	// and are checked by holder alone, since they were opened for the gateway
+	// user of that name. The gateway calls it before linking an existing
+	// account to a user.
+	VerifyAccountHolder(ctx context.Context, in *VerifyAccountHolderRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	// Opens an account with the bank's opening balance. The bank is the only
+	// writer of its account store; the gateway calls these on users' behalf.
+	OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*AccountResponse, error)
+	CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*CloseAccountResponse, error)
 	// Existing operations.
 	DebitAccount(ctx context.Context, in *DebitCreditRequest, opts ...grpc.CallOption) (*DebitCreditResponse, error)
 	CreditAccount(ctx context.Context, in *DebitCreditRequest, opts ...grpc.CallOption) (*DebitCreditResponse, error)
@@ -337,6 +889,46 @@ func (c *bankClient) GetBalance(ctx context.Context, in *BalanceRequest, opts ..
 	return out, nil
 }
 
+func (c *bankClient) GetAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, Bank_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bankClient) VerifyAccountHolder(ctx context.Context, in *VerifyAccountHolderRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, Bank_VerifyAccountHolder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bankClient) OpenAccount(ctx context.Context, in *OpenAccountRequest, opts ...grpc.CallOption) (*AccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AccountResponse)
+	err := c.cc.Invoke(ctx, Bank_OpenAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bankClient) CloseAccount(ctx context.Context, in *AccountRequest, opts ...grpc.CallOption) (*CloseAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CloseAccountResponse)
+	err := c.cc.Invoke(ctx, Bank_CloseAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *bankClient) DebitAccount(ctx context.Context, in *DebitCreditRequest, opts ...grpc.CallOption) (*DebitCreditResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DebitCreditResponse)
@@ -421,10 +1013,22 @@ func (c *bankClient) AbortCredit(ctx context.Context, in *DebitCreditRequest, op
 // All implementations must embed UnimplementedBankServer
 // for forward compatibility.
 //
-// Bank Service
+// Bank Service. Failed calls return a non-OK status with an ErrorDetail.
 type BankServer interface {
 	ProcessTransaction(context.Context, *TransactionRequest) (*TransactionResponse, error)
 	GetBalance(context.Context, *BalanceRequest) (*BalanceResponse, error)
+	// Looks up an account holder.
+	GetAccount(context.Context, *AccountRequest) (*AccountResponse, error)
+	// Checks that username holds the account and that password matches its
+	// bank password. Accounts opened with OpenAccount have no bank password
+	// and are checked by holder alone, since they were opened for the gateway
+	// user of that name. The gateway calls it before linking an existing
+	// account to a user.
+	VerifyAccountHolder(context.Context, *VerifyAccountHolderRequest) (*AccountResponse, error)
+	// Opens an account with the bank's opening balance. The bank is the only
+	// writer of its account store; the gateway calls these on users' behalf.
+	OpenAccount(context.Context, *OpenAccountRequest) (*AccountResponse, error)
+	CloseAccount(context.Context, *AccountRequest) (*CloseAccountResponse, error)
 	// Existing operations.
 	DebitAccount(context.Context, *DebitCreditRequest) (*DebitCreditResponse, error)
 	CreditAccount(context.Context, *DebitCreditRequest) (*DebitCreditResponse, error)
@@ -451,6 +1055,18 @@ func (UnimplementedBankServer) ProcessTransaction(context.Context, *TransactionR
 func (UnimplementedBankServer) GetBalance(context.Context, *BalanceRequest) (*BalanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
 }
+func (UnimplementedBankServer) GetAccount(context.Context, *AccountRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedBankServer) VerifyAccountHolder(context.Context, *VerifyAccountHolderRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyAccountHolder not implemented")
+}
+func (UnimplementedBankServer) OpenAccount(context.Context, *OpenAccountRequest) (*AccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenAccount not implemented")
+}
+func (UnimplementedBankServer) CloseAccount(context.Context, *AccountRequest) (*CloseAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseAccount not implemented")
+}
 func (UnimplementedBankServer) DebitAccount(context.Context, *DebitCreditRequest) (*DebitCreditResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DebitAccount not implemented")
 }
@@ -532,6 +1148,78 @@ func _Bank_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Bank_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BankServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bank_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BankServer).GetAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bank_VerifyAccountHolder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyAccountHolderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BankServer).VerifyAccountHolder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bank_VerifyAccountHolder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BankServer).VerifyAccountHolder(ctx, req.(*VerifyAccountHolderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bank_OpenAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BankServer).OpenAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bank_OpenAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BankServer).OpenAccount(ctx, req.(*OpenAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bank_CloseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BankServer).CloseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bank_CloseAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BankServer).CloseAccount(ctx, req.(*AccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Bank_DebitAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DebitCreditRequest)
 	if err := dec(in); err != nil {
@@ -691,6 +1379,22 @@ var Bank_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetBalance",
 			Handler:    _Bank_GetBalance_Handler,
 		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _Bank_GetAccount_Handler,
+		},
+		{
+			MethodName: "VerifyAccountHolder",
+			Handler:    _Bank_VerifyAccountHolder_Handler,
+		},
+		{
+			MethodName: "OpenAccount",
+			Handler:    _Bank_OpenAccount_Handler,
+		},
+		{
+			MethodName: "CloseAccount",
+			Handler:    _Bank_CloseAccount_Handler,
+		},
 		{
 			MethodName: "DebitAccount",
 			Handler:    _Bank_DebitAccount_Handler,
@@ -95,6 +95,22 @@ if %errorlevel% neq 0 (
     exit /b 1
 )
 
+:: Each bank gets its own certificate: the gateway only accepts BankRegister
+:: from a certificate with CN=<bank name> and OU=bank.
+for %%B in (ICICI SBI) do (
+    echo Generating %%B bank certificate...
+    openssl req -nodes -newkey rsa:2048 -keyout certs\%%B.key -out certs\%%B.csr -subj "/CN=%%B/OU=bank"
+    if errorlevel 1 (
+        echo ❌ Error: Failed to generate %%B CSR.
+        exit /b 1
+    )
+    openssl x509 -req -in certs\%%B.csr -CA certs\ca.pem -CAkey certs\ca.key -CAcreateserial -out certs\%%B.pem -days 365
+    if errorlevel 1 (
+        echo ❌ Error: Failed to sign %%B certificate.
+        exit /b 1
+    )
+)
+
 echo Cleaning up temporary files...
 del certs\*.csr
 del certs\*.srl
@@ -116,11 +132,11 @@ timeout /t 3 >nul
 
 :: Start Bank Servers.
 echo 🚀 Starting Bank Server 1 on port 50052...
-start "Bank Server 1" cmd /k "cd bank && go run bank.go -port=50052 -bank=ICICI"
+start "Bank Server 1" cmd /k "cd bank && go run . -port=50052 -bank=ICICI"
 timeout /t 2 >nul
 
 echo 🚀 Starting Bank Server 2 on port 50053...
-start "Bank Server 2" cmd /k "cd bank && go run bank.go -port=50053 -bank=SBI"
+start "Bank Server 2" cmd /k "cd bank && go run . -port=50053 -bank=SBI"
 timeout /t 2 >nul
 
 :: Start Client Instances.
@@ -0,0 +1,173 @@
+// Package safefile writes data files so that a crash never leaves one half
+// written, and checks them when they are read back.
+//
+// WriteFile writes a temporary file next to the target, syncs it and renames
+// it into place, keeping the previous contents in <file>.bak. The data is
+// followed by a checksum line, "#sha256:<hex>", which ReadFile checks and
+// strips. A file that is damaged, or missing because a crash fell between
+// the two renames, is replaced by its backup; the damaged copy is kept as
+// <file>.damaged for inspection.
+//
+// A file without a checksum line is returned as it is: files written before
+// checksums were added, and files edited by hand, have none. Whoever edits a
+// file by hand should delete its checksum line, or the edit is taken for
+// damage and the backup is used instead.
+//
+// Append-only logs cannot carry a checksum of the whole file; they check
+// each record themselves and use Replace when they are rewritten.
+package safefile
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// checksumPrefix starts the last line of a file written by WriteFile.
+const checksumPrefix = "#sha256:"
+
+// WriteFile replaces path with data followed by its checksum line, keeping
+// the old file as path.bak.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	var buf bytes.Buffer
+	buf.Write(data)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	buf.WriteString(checksumPrefix + hex.EncodeToString(sum[:]) + "\n")
+
+	tmp := path + ".tmp"
+	if err := writeSynced(tmp, buf.Bytes(), perm); err != nil {
+		return err
+	}
+	if err := os.Rename(path, path+".bak"); err != nil && !os.IsNotExist(err) {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// Replace atomically replaces path with data, without a checksum or a
+// backup.
+func Replace(path string, data []byte, perm os.FileMode) error {
+	tmp := path + ".tmp"
+	if err := writeSynced(tmp, data, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// ReadFile returns the contents of path without its checksum line. If path
+// is damaged or missing and path.bak is good, the backup is put back in
+// place and its contents returned. If neither exists the error from reading
+// path is returned, so os.IsNotExist works on it.
+func ReadFile(path string) ([]byte, error) {
+	data, _, err := ReadFileRestored(path)
+	return data, err
+}
+
+// ReadFileRestored is ReadFile that also reports whether the backup was
+// used, for callers that must allow for the last write having been lost.
+func ReadFileRestored(path string) (data []byte, restored bool, err error) {
+	data, readErr := os.ReadFile(path)
+	if readErr == nil {
+		if data, err = verify(data); err == nil {
+			return data, false, nil
+		}
+	} else if !os.IsNotExist(readErr) {
+		return nil, false, readErr
+	}
+
+	bak := path + ".bak"
+	raw, bakErr := os.ReadFile(bak)
+	if os.IsNotExist(bakErr) {
+		if readErr != nil {
+			return nil, false, readErr
+		}
+		return nil, false, fmt.Errorf("%s is damaged (%v) and there is no backup", path, err)
+	}
+	if bakErr != nil {
+		return nil, false, bakErr
+	}
+	backup, bakErr := verify(raw)
+	if bakErr != nil {
+		if readErr != nil {
+			return nil, false, fmt.Errorf("%s is missing and its backup is damaged (%v)", path, bakErr)
+		}
+		return nil, false, fmt.Errorf("%s is damaged (%v) and so is its backup (%v)", path, err, bakErr)
+	}
+
+	if readErr == nil {
+		log.Printf("SafeFile: %s is damaged (%v); restoring it from %s and keeping the damaged copy as %s.damaged", path, err, filepath.Base(bak), filepath.Base(path))
+		if err := os.Rename(path, path+".damaged"); err != nil {
+			return nil, false, err
+		}
+	} else {
+		log.Printf("SafeFile: %s is missing; restoring it from %s", path, filepath.Base(bak))
+	}
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(bak); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := Replace(path, raw, perm); err != nil {
+		return nil, false, err
+	}
+	return backup, true, nil
+}
+
+// verify checks and strips the checksum line of data, if it has one.
+func verify(data []byte) ([]byte, error) {
+	body := bytes.TrimSuffix(data, []byte("\n"))
+	cut := bytes.LastIndexByte(body, '\n') + 1
+	last := body[cut:]
+	if !bytes.HasPrefix(last, []byte(checksumPrefix)) {
+		return data, nil
+	}
+	body = data[:cut]
+	sum := sha256.Sum256(body)
+	if string(last[len(checksumPrefix):]) != hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+	return body, nil
+}
+
+func writeSynced(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir makes a rename in dir durable. Directories cannot be synced on
+// every platform, so failing to is not an error.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return nil
+	}
+	d.Sync()
+	return d.Close()
+}
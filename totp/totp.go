@@ -0,0 +1,112 @@
+// Package totp implements time-based one-time passwords (RFC 6238) on top of
+// HOTP (RFC 4226), with the parameters authenticator apps assume by default:
+// HMAC-SHA1, six digits and a 30 second step. It needs nothing but the
+// shared secret and a clock, so codes work offline.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Digits is the length of a code.
+	Digits = 6
+	// Period is how long each code is valid.
+	Period = 30 * time.Second
+	// Skew is how many periods of clock drift either way Verify accepts.
+	Skew = 1
+	// secretBytes is the secret size RFC 4226 recommends for HMAC-SHA1.
+	secretBytes = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random secret in the unpadded base32 form
+// authenticator apps accept.
+func GenerateSecret() (string, error) {
+	key := make([]byte, secretBytes)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(key), nil
+}
+
+// URI returns the otpauth:// URI that authenticator apps import, usually
+// from a QR code.
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(Digits)},
+		"period":    {fmt.Sprint(int(Period / time.Second))},
+	}
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %v", err)
+	}
+	return key, nil
+}
+
+// hotp is the RFC 4226 code for counter.
+func hotp(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", Digits, value%mod)
+}
+
+// Step returns the time step t falls in.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code for secret at t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, uint64(Step(t))), nil
+}
+
+// Verify checks code against secret for the steps within Skew of t. It
+// returns the step that matched, so that callers can refuse a code that has
+// already been used.
+func Verify(secret, code string, t time.Time) (step int64, ok bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+	now := Step(t)
+	for s := now - Skew; s <= now+Skew; s++ {
+		if s >= 0 && hmac.Equal([]byte(hotp(key, uint64(s))), []byte(code)) {
+			return s, true
+		}
+	}
+	return 0, false
+}
@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
 	"os"
@@ -12,6 +11,7 @@ import (
 	"sync"
 
 	pb "payment_gateway/proto"
+	"payment_gateway/safefile"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/peer"
@@ -32,10 +32,11 @@ func loadCounter() {
 	counterMutex.Lock()
 	defer counterMutex.Unlock()
 
-	// Check if counter file exists
-	if _, err := os.Stat(counterFile); os.IsNotExist(err) {
+	// Read counter from file; a damaged file is replaced by its backup
+	data, restored, err := safefile.ReadFileRestored(counterFile)
+	if os.IsNotExist(err) {
 		// File doesn't exist, create it with initial value
-		err := ioutil.WriteFile(counterFile, []byte("1"), 0644)
+		err := safefile.WriteFile(counterFile, []byte("1"), 0644)
 		if err != nil {
 			log.Printf("[TransactionID Server] WARNING: Failed to create counter file: %v", err)
 		}
@@ -43,9 +44,6 @@ func loadCounter() {
 		transactionCounter = 1
 		return
 	}
-
-	// Read counter from file
-	data, err := ioutil.ReadFile(counterFile)
 	if err != nil {
 		log.Printf("[TransactionID Server] WARNING: Failed to read counter file: %v", err)
 		return
@@ -58,17 +56,23 @@ func loadCounter() {
 		return
 	}
 
+	if restored {
+		// The backup is one save behind, and that save may have handed
+		// out an ID already
+		value++
+		log.Printf("[TransactionID Server] WARNING: Counter restored from backup; skipping to %d", value)
+	}
+
 	transactionCounter = value
 	log.Printf("[TransactionID Server] Loaded counter value: %d", transactionCounter)
 }
 
-// saveCounter writes the current transaction counter to file
-func saveCounter() error {
-	counterMutex.Lock()
-	defer counterMutex.Unlock()
-
-	// Write counter to file
-	err := ioutil.WriteFile(counterFile, []byte(fmt.Sprintf("%d", transactionCounter)), 0644)
+// saveCounterLocked writes the current transaction counter to file. The
+// caller must hold counterMutex.
+func saveCounterLocked() error {
+	// Write counter to file, replacing the old one only once the new one
+	// is on disk
+	err := safefile.WriteFile(counterFile, []byte(fmt.Sprintf("%d", transactionCounter)), 0644)
 	if err != nil {
 		log.Printf("[TransactionID Server] ERROR: Failed to save counter: %v", err)
 		return err
@@ -86,12 +90,16 @@ func (s *server) GetNewTransactionID(ctx context.Context, req *pb.TransactionIDR
 	counterMutex.Lock()
 	transactionID := fmt.Sprintf("TXN-%06d", transactionCounter)
 	transactionCounter++
-	counterMutex.Unlock()
 
-	// Save the updated counter value
-	if err := saveCounter(); err != nil {
-		log.Printf("[TransactionID Server] WARNING: Failed to persist counter after generating ID %s", transactionID)
+	// Save the updated counter value before handing the ID out, so that a
+	// restart can never assign it again
+	if err := saveCounterLocked(); err != nil {
+		transactionCounter--
+		counterMutex.Unlock()
+		log.Printf("[TransactionID Server] WARNING: Failed to persist counter; ID %s not assigned", transactionID)
+		return nil, fmt.Errorf("could not persist transaction counter: %v", err)
 	}
+	counterMutex.Unlock()
 
 	p, ok := peer.FromContext(ctx)
 	clientAddress := "UNKNOWN"
@@ -0,0 +1,114 @@
+// Package txnkey builds, validates and formats the idempotency keys used for
+// the legs of a distributed transfer. The canonical text form, used in bank
+// logs and files, is "<global_id>:<leg>:<leg_index>", e.g. TXN-000001:debit:0.
+package txnkey
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	pb "payment_gateway/proto"
+)
+
+// MaxGlobalIDLength bounds the global transaction id.
+const MaxGlobalIDLength = 64
+
+// New returns the key for one leg of globalID.
+func New(globalID string, leg pb.LegType, legIndex int32) *pb.TransactionKey {
+	return &pb.TransactionKey{GlobalId: globalID, Leg: leg, LegIndex: legIndex}
+}
+
+// LegName is the lower-case name of a leg type as used in the text form.
+func LegName(leg pb.LegType) string {
+	switch leg {
+	case pb.LegType_LEG_DEBIT:
+		return "debit"
+	case pb.LegType_LEG_CREDIT:
+		return "credit"
+	}
+	return ""
+}
+
+// ParseLeg is the inverse of LegName.
+func ParseLeg(name string) (pb.LegType, bool) {
+	switch name {
+	case "debit":
+		return pb.LegType_LEG_DEBIT, true
+	case "credit":
+		return pb.LegType_LEG_CREDIT, true
+	}
+	return pb.LegType_LEG_TYPE_UNSPECIFIED, false
+}
+
+// ValidateGlobalID checks a transfer id for use in a key.
+func ValidateGlobalID(id string) error {
+	if id == "" {
+		return fmt.Errorf("global id is empty")
+	}
+	if len(id) > MaxGlobalIDLength {
+		return fmt.Errorf("global id is longer than %d characters", MaxGlobalIDLength)
+	}
+	if strings.ContainsAny(id, ":,\r\n") {
+		return fmt.Errorf("global id %q contains a reserved character", id)
+	}
+	return nil
+}
+
+// Validate checks that key is well formed and is a leg of type want.
+func Validate(key *pb.TransactionKey, want pb.LegType) error {
+	if key == nil {
+		return fmt.Errorf("transaction key is missing")
+	}
+	if err := ValidateGlobalID(key.GlobalId); err != nil {
+		return err
+	}
+	if LegName(key.Leg) == "" {
+		return fmt.Errorf("transaction key has no leg type")
+	}
+	if key.Leg != want {
+		return fmt.Errorf("transaction key is a %s leg, expected %s", LegName(key.Leg), LegName(want))
+	}
+	if key.LegIndex < 0 {
+		return fmt.Errorf("leg index %d is negative", key.LegIndex)
+	}
+	return nil
+}
+
+// ValidateRequest checks the key of a DebitCreditRequest against the leg
+// type of the RPC it was sent to and against the request's transaction id.
+func ValidateRequest(req *pb.DebitCreditRequest, want pb.LegType) error {
+	if err := Validate(req.Key, want); err != nil {
+		return err
+	}
+	if req.TransactionId != req.Key.GlobalId {
+		return fmt.Errorf("transaction id %q does not match key global id %q", req.TransactionId, req.Key.GlobalId)
+	}
+	return nil
+}
+
+// Format renders key in its canonical text form.
+func Format(key *pb.TransactionKey) string {
+	return fmt.Sprintf("%s:%s:%d", key.GlobalId, LegName(key.Leg), key.LegIndex)
+}
+
+// Parse reads the canonical text form produced by Format.
+func Parse(s string) (*pb.TransactionKey, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("key %q is not of the form <global_id>:<leg>:<index>", s)
+	}
+	leg, ok := ParseLeg(parts[1])
+	if !ok {
+		return nil, fmt.Errorf("key %q has unknown leg %q", s, parts[1])
+	}
+	index, err := strconv.ParseInt(parts[2], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("key %q has invalid leg index: %v", s, err)
+	}
+	key := New(parts[0], leg, int32(index))
+	if err := Validate(key, leg); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,311 @@
+// Package validate checks PaymentGateway and Bank requests before their
+// handlers run. Every problem found is reported as a field violation in a
+// single InvalidArgument status, so callers can show all of them at once.
+package validate
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"payment_gateway/money"
+	"payment_gateway/password"
+	pb "payment_gateway/proto"
+	"payment_gateway/txnkey"
+)
+
+// Field limits.
+const (
+	MaxUsernameLength  = 64
+	MaxPasswordLength  = password.MaxLength
+	MaxAccountIDLength = 32
+	MaxBankNameLength  = 32
+	MaxAddressLength   = 255
+	MaxTokenLength     = 4096
+	MaxOTPCodeLength   = 32
+	MinPINLength       = 4
+	MaxPINLength       = 12
+	// MaxAmountMinorUnits caps a single transfer (1,000,000,000.00 INR).
+	MaxAmountMinorUnits = 100_000_000_000
+)
+
+var (
+	// Account ids and bank names end up in file names and CSV lines.
+	identifierPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+	usernamePattern   = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._@-]*$`)
+)
+
+// BankLookup reports whether a bank name is known to the caller.
+type BankLookup func(bankName string) bool
+
+// Violations collects field-level problems with one request.
+type Violations struct {
+	list []*errdetails.BadRequest_FieldViolation
+}
+
+// Add records a problem with field.
+func (v *Violations) Add(field, format string, args ...interface{}) {
+	v.list = append(v.list, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	})
+}
+
+// Err returns nil if nothing was recorded, otherwise an InvalidArgument
+// status carrying a BadRequest detail and an INVALID_REQUEST ErrorDetail.
+func (v *Violations) Err() error {
+	if len(v.list) == 0 {
+		return nil
+	}
+	parts := make([]string, len(v.list))
+	for i, fv := range v.list {
+		parts[i] = fv.Field + ": " + fv.Description
+	}
+	st := status.New(codes.InvalidArgument, "invalid request: "+strings.Join(parts, "; "))
+	if detailed, err := st.WithDetails(
+		&pb.ErrorDetail{Reason: pb.ErrorReason_INVALID_REQUEST},
+		&errdetails.BadRequest{FieldViolations: v.list},
+	); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func (v *Violations) identifier(field, value, what string, maxLength int) {
+	switch {
+	case value == "":
+		v.Add(field, "%s is required", what)
+	case len(value) > maxLength:
+		v.Add(field, "%s is longer than %d characters", what, maxLength)
+	case !identifierPattern.MatchString(value):
+		v.Add(field, "%s may only contain letters, digits, '-' and '_'", what)
+	}
+}
+
+// AccountID checks the format of an account id.
+func (v *Violations) AccountID(field, id string) {
+	v.identifier(field, id, "account id", MaxAccountIDLength)
+}
+
+// BankName checks the format of a bank name and, if known is not nil, that
+// the bank exists.
+func (v *Violations) BankName(field, name string, known BankLookup) {
+	before := len(v.list)
+	v.identifier(field, name, "bank name", MaxBankNameLength)
+	if len(v.list) == before && known != nil && !known(name) {
+		v.Add(field, "bank '%s' is not registered", name)
+	}
+}
+
+// Username checks the format of a gateway username.
+func (v *Violations) Username(field, name string) {
+	switch {
+	case name == "":
+		v.Add(field, "username is required")
+	case len(name) > MaxUsernameLength:
+		v.Add(field, "username is longer than %d characters", MaxUsernameLength)
+	case !usernamePattern.MatchString(name):
+		v.Add(field, "username may only contain letters, digits, '.', '@', '-' and '_'")
+	}
+}
+
+// Password checks the length of a password.
+func (v *Violations) Password(field, password string) {
+	switch {
+	case password == "":
+		v.Add(field, "password is required")
+	case len(password) > MaxPasswordLength:
+		v.Add(field, "password is longer than %d characters", MaxPasswordLength)
+	}
+}
+
+// OTPCode checks the length of an optional one-time code, which is either a
+// TOTP code or a recovery code.
+func (v *Violations) OTPCode(field, code string) {
+	if len(code) > MaxOTPCodeLength {
+		v.Add(field, "code is longer than %d characters", MaxOTPCodeLength)
+	}
+}
+
+// PIN checks that a transaction PIN is MinPINLength to MaxPINLength digits.
+func (v *Violations) PIN(field, pin string) {
+	if len(pin) < MinPINLength || len(pin) > MaxPINLength {
+		v.Add(field, "PIN must be %d to %d digits", MinPINLength, MaxPINLength)
+		return
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			v.Add(field, "PIN may only contain digits")
+			return
+		}
+	}
+}
+
+// TransactionID checks a global transfer id.
+func (v *Violations) TransactionID(field, id string) {
+	if err := txnkey.ValidateGlobalID(id); err != nil {
+		v.Add(field, "%v", err)
+	}
+}
+
+// Amount checks that m is a positive amount within the transfer limit.
+func (v *Violations) Amount(field string, m *pb.Money) {
+	if err := money.ValidatePositive(m); err != nil {
+		v.Add(field, "%v", err)
+		return
+	}
+	if m.MinorUnits > MaxAmountMinorUnits {
+		v.Add(field, "amount %s exceeds the limit of %s", money.Format(m),
+			money.Format(money.New(MaxAmountMinorUnits, m.CurrencyCode)))
+	}
+}
+
+// Address checks a host:port bank address.
+func (v *Violations) Address(field, addr string) {
+	if len(addr) > MaxAddressLength {
+		v.Add(field, "address is longer than %d characters", MaxAddressLength)
+		return
+	}
+	if _, port, err := net.SplitHostPort(addr); err != nil || port == "" {
+		v.Add(field, "address %q is not of the form host:port", addr)
+	}
+}
+
+// Request validates any PaymentGateway or Bank request sent to the RPC with
+// the given full method name. Bank names are checked against known where
+// the request refers to an existing bank. Requests of other types are
+// accepted.
+func Request(method string, req interface{}, known BankLookup) error {
+	var v Violations
+	switch r := req.(type) {
+	case *pb.RegisterRequest:
+		v.Username("username", r.Username)
+		v.Password("password", r.Password)
+		if !r.OpenAccount || r.AccountId != "" {
+			v.AccountID("account_id", r.AccountId)
+		}
+		v.BankName("bank_name", r.BankName, known)
+	case *pb.AuthRequest:
+		v.Username("username", r.Username)
+		v.Password("password", r.Password)
+		if r.AccountId != "" {
+			v.AccountID("account_id", r.AccountId)
+		}
+		v.OTPCode("otp_code", r.OtpCode)
+	case *pb.TransferRequest:
+		v.TransactionID("transaction_id", r.TransactionId)
+		v.AccountID("from_account", r.FromAccount)
+		v.AccountID("to_account", r.ToAccount)
+		v.BankName("from_bank", r.FromBank, known)
+		v.BankName("to_bank", r.ToBank, known)
+		v.Amount("amount", r.Amount)
+		if r.FromBank == r.ToBank && r.FromAccount == r.ToAccount && r.FromAccount != "" {
+			v.Add("to_account", "must differ from from_account")
+		}
+		v.OTPCode("otp_code", r.OtpCode)
+		if r.Pin != "" {
+			v.PIN("pin", r.Pin)
+		}
+	case *pb.EnrollTotpRequest:
+		v.OTPCode("otp_code", r.OtpCode)
+	case *pb.ConfirmTotpRequest:
+		if r.OtpCode == "" {
+			v.Add("otp_code", "code is required")
+		}
+		v.OTPCode("otp_code", r.OtpCode)
+	case *pb.BalanceRequest:
+		v.AccountID("account_id", r.AccountId)
+		v.BankName("bank_name", r.BankName, known)
+	case *pb.AccountRequest:
+		v.AccountID("account_id", r.AccountId)
+		v.BankName("bank_name", r.BankName, known)
+	case *pb.OpenAccountRequest:
+		v.BankName("bank_name", r.BankName, known)
+		if r.AccountId != "" {
+			v.AccountID("account_id", r.AccountId)
+		}
+		// The gateway sets the holder from the caller's token; banks need it.
+		if r.Username != "" || method == pb.Bank_OpenAccount_FullMethodName {
+			v.Username("username", r.Username)
+		}
+	case *pb.SetTransactionPinRequest:
+		v.Password("password", r.Password)
+		if r.NewPin != "" {
+			v.PIN("new_pin", r.NewPin)
+		}
+	case *pb.ChangePasswordRequest:
+		v.Password("old_password", r.OldPassword)
+		v.Password("new_password", r.NewPassword)
+		if r.NewPassword != "" && r.NewPassword == r.OldPassword {
+			v.Add("new_password", "must differ from old_password")
+		}
+	case *pb.BankRegisterRequest:
+		// The bank is registering, so it need not be known yet.
+		v.BankName("bank_name", r.BankName, nil)
+		v.Address("bank_address", r.BankAddress)
+	case *pb.TransactionDecisionRequest:
+		// Banks ask before they have re-registered with a restarted gateway.
+		v.TransactionID("transaction_id", r.TransactionId)
+		v.BankName("bank_name", r.BankName, nil)
+	case *pb.TransferStatusRequest:
+		v.TransactionID("transaction_id", r.TransactionId)
+	case *pb.RefreshTokenRequest:
+		switch {
+		case r.Token == "":
+			v.Add("token", "token is required")
+		case len(r.Token) > MaxTokenLength:
+			v.Add("token", "token is longer than %d characters", MaxTokenLength)
+		}
+	case *pb.TransactionRequest:
+		v.TransactionID("transaction_id", r.TransactionId)
+		v.AccountID("from_account", r.FromAccount)
+		v.AccountID("to_account", r.ToAccount)
+		v.BankName("from_bank", r.FromBank, nil)
+		v.BankName("to_bank", r.ToBank, nil)
+		v.Amount("amount", r.Amount)
+	case *pb.DebitCreditRequest:
+		v.AccountID("account_id", r.AccountId)
+		v.AccountID("counterparty_account", r.CounterpartyAccount)
+		v.Amount("amount", r.Amount)
+		v.TransactionID("transaction_id", r.TransactionId)
+		if err := txnkey.Validate(r.Key, legForMethod(method, r.Key)); err != nil {
+			v.Add("key", "%v", err)
+		} else if r.Key.GlobalId != r.TransactionId {
+			v.Add("key.global_id", "must equal transaction_id")
+		}
+		if r.PrepareDeadlineUnixMs < 0 {
+			v.Add("prepare_deadline_unix_ms", "must not be negative")
+		}
+	}
+	return v.Err()
+}
+
+// legForMethod is the leg type a Bank RPC expects, e.g. LEG_DEBIT for
+// PrepareDebit. Methods not tied to a leg accept the key's own leg.
+func legForMethod(method string, key *pb.TransactionKey) pb.LegType {
+	switch {
+	case strings.HasSuffix(method, "Debit") || strings.HasSuffix(method, "DebitAccount"):
+		return pb.LegType_LEG_DEBIT
+	case strings.HasSuffix(method, "Credit") || strings.HasSuffix(method, "CreditAccount"):
+		return pb.LegType_LEG_CREDIT
+	}
+	return key.GetLeg()
+}
+
+// UnaryServerInterceptor rejects invalid requests with InvalidArgument
+// before they reach the handler.
+func UnaryServerInterceptor(known BankLookup) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := Request(info.FullMethod, req, known); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
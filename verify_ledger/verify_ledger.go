@@ -0,0 +1,155 @@
+// verify_ledger walks a bank's ledger and checks that its history was not
+// altered, from this directory:
+//
+//	go run verify_ledger.go -bank=ICICI
+//
+// Every line must link to the one before it and balance, and every
+// checkpoint must carry a valid signature by the bank's certificate, which
+// must itself be issued by the CA to the bank. The first line that fails is
+// reported as the first broken link and the command exits with status 1,
+// as it does for a ledger with no signed checkpoint at all. Entries after
+// the last checkpoint are chained but not yet signed; a summary says how
+// many there are.
+//
+// The bank may be running: only whole lines are read.
+package main
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"payment_gateway/journal"
+)
+
+var (
+	dataDir  = flag.String("dir", "..", "Directory holding <BANK>_ledger.txt")
+	bankName = flag.String("bank", "", "Bank whose ledger to verify")
+	certFile = flag.String("cert", "", "Certificate the bank signs checkpoints with (default <dir>/certs/<bank>.pem)")
+	caFile   = flag.String("ca", "", "CA certificate that issued -cert (default <dir>/certs/ca.pem)")
+)
+
+func readCertificate(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%s holds no certificate", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// bankCertificate loads the bank's certificate and checks that the CA
+// issued it to the bank. It is checked as of when it was issued, so that a
+// ledger can still be verified once the certificate has expired.
+func bankCertificate() (*x509.Certificate, error) {
+	cert, err := readCertificate(*certFile)
+	if err != nil {
+		return nil, err
+	}
+	ca, err := readCertificate(*caFile)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:       roots,
+		CurrentTime: cert.NotBefore,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", *certFile, err)
+	}
+	if cert.Subject.CommonName != *bankName {
+		return nil, fmt.Errorf("%s was issued to %q, not %q", *certFile, cert.Subject.CommonName, *bankName)
+	}
+	return cert, nil
+}
+
+func main() {
+	flag.Parse()
+	if *bankName == "" {
+		log.Fatalf("[VerifyLedger] -bank is required")
+	}
+	if *certFile == "" {
+		*certFile = filepath.Join(*dataDir, "certs", *bankName+".pem")
+	}
+	if *caFile == "" {
+		*caFile = filepath.Join(*dataDir, "certs", "ca.pem")
+	}
+	cert, err := bankCertificate()
+	if err != nil {
+		log.Fatalf("[VerifyLedger] %v", err)
+	}
+	filename := filepath.Join(*dataDir, *bankName+"_ledger.txt")
+	f, err := os.Open(filename)
+	if err != nil {
+		log.Fatalf("[VerifyLedger] %v", err)
+	}
+	defer f.Close()
+
+	reader := journal.NewReader(f)
+	var checkpoints int
+	var signedUpTo uint64
+	var signedAt time.Time
+	var broken error
+	for {
+		e, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, journal.ErrTorn) {
+			log.Printf("[VerifyLedger] %s ends in a torn entry, left by a crash while writing; the bank drops it when it starts.", filename)
+			break
+		}
+		if err == nil && e.Kind == journal.KindCheckpoint {
+			switch {
+			case e.Reference != *bankName:
+				err = &journal.BrokenLink{Line: reader.Line(), Reason: fmt.Sprintf("checkpoint %d is for bank %q", e.ID, e.Reference)}
+			case journal.VerifySignature(e, cert.PublicKey) != nil:
+				err = &journal.BrokenLink{Line: reader.Line(), Reason: fmt.Sprintf("checkpoint %d is not signed by %s", e.ID, *certFile)}
+			}
+		}
+		if err != nil {
+			broken = err
+			break
+		}
+		if e.Kind == journal.KindCheckpoint {
+			checkpoints++
+			signedUpTo, signedAt = e.ID, e.Time
+		}
+	}
+
+	if broken != nil {
+		var link *journal.BrokenLink
+		if !errors.As(broken, &link) {
+			log.Fatalf("[VerifyLedger] %v", broken)
+		}
+		log.Printf("[VerifyLedger] %s: FIRST BROKEN LINK at %v", filename, broken)
+		if signedUpTo > 0 {
+			log.Printf("[VerifyLedger] Entries 1-%d are covered by the signed checkpoint of %s.", signedUpTo, signedAt.Format(time.RFC3339))
+		} else {
+			log.Printf("[VerifyLedger] No signed checkpoint precedes it.")
+		}
+		os.Exit(1)
+	}
+	log.Printf("[VerifyLedger] %s: %d entries, every link intact.", filename, reader.LastID())
+	if checkpoints == 0 {
+		log.Printf("[VerifyLedger] NO SIGNED CHECKPOINT: the chain shows the entries agree with each other, but not that they are the bank's.")
+		os.Exit(1)
+	}
+	log.Printf("[VerifyLedger] %d signed checkpoint(s); the last, of %s, covers entries 1-%d.", checkpoints, signedAt.Format(time.RFC3339), signedUpTo)
+	if unsigned := reader.LastID() - signedUpTo; unsigned > 0 {
+		log.Printf("[VerifyLedger] %d entries after it are not signed yet.", unsigned)
+	}
+}